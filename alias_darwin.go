@@ -1,15 +1,16 @@
 package cocoa
 
 import (
-	"bytes"
 	"encoding/binary"
 	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
 	"os/user"
 	"path/filepath"
 	"strconv"
-	"strings"
 	"syscall"
 
 	"github.com/mattetti/cocoa/darwin"
@@ -38,89 +39,219 @@ import (
 */
 
 // IsAlias returns positively if the passed file path is an alias.
+// Errors are ignored and reported as not being an alias, use IsAliasErr to
+// tell the two apart.
 func IsAlias(src string) bool {
+	isAlias, _ := IsAliasErr(src)
+	return isAlias
+}
+
+// IsAliasErr returns positively if the passed file path is an alias.
+// An error is returned if the file attributes can't be checked.
+func IsAliasErr(src string) (bool, error) {
 	srcPath, err := filepath.Abs(src)
 	if err != nil {
-		return false
+		return false, fmt.Errorf("%s can't be converted to an absolute path - %s", src, err)
 	}
 	srcPath = filepath.Clean(srcPath)
 
-	buf := make([]byte, 256)
-	fileAttrs, err := darwin.GetAttrList(srcPath,
-		darwin.AttrListMask{
-			CommonAttr: darwin.ATTR_CMN_OBJTYPE | darwin.ATTR_CMN_FNDRINFO,
-		},
-		buf, darwin.FSOPT_NOFOLLOW)
+	// only read the finder info, the finder flags are stored big endian
+	// after the type and creator codes.
+	info := make([]byte, 32)
+	n, err := darwin.GetxattrBuf(srcPath, "com.apple.FinderInfo", info, darwin.XATTR_NOFOLLOW)
+	if err == syscall.ENOATTR {
+		// files without finder info can't be aliases
+		return false, nil
+	}
 	if err != nil {
-		log.Printf("failed to retrieve file attribute list - %s", err)
-		return false
+		return false, fmt.Errorf("failed to read the finder info of %s - %s", srcPath, err)
+	}
+	if n < 10 {
+		return false, nil
 	}
+	return binary.BigEndian.Uint16(info[8:])&darwin.FFKIsAlias > 0, nil
+}
 
-	return fileAttrs.FileInfo.FinderFlags&darwin.FFKIsAlias > 0
+// RemoveAlias clears the Finder alias flag of the file at path so it's no
+// longer considered an alias. The file content isn't modified.
+func RemoveAlias(path string) error {
+	if err := darwin.UnsetAlias(path); err != nil {
+		return fmt.Errorf("failed to remove the alias flag of %s - %s", path, err)
+	}
+	return nil
 }
 
 // Alias acts like os.Symlink but instead of creating a symlink, a bookmark is stored.
 func Alias(src, dst string) error {
-	srcPath, err := filepath.Abs(src)
+	return AliasWithOpts(src, dst, BookmarkOpts{})
+}
+
+// CreateAliasFile creates a Finder alias to target at dst, the same way Finder
+// does it. If dst is an existing directory, the alias is created inside of it
+// and named after the target ("<target name> alias"). Unlike Alias, the
+// Finder type and creator are set according to the kind of target.
+func CreateAliasFile(target, dst string) error {
+	targetInfo, err := os.Stat(target)
 	if err != nil {
-		return fmt.Errorf("failed to get the path of the source - %s", err)
+		return fmt.Errorf("failed to stat the alias target - %s", err)
 	}
-	srcPath = filepath.Clean(srcPath)
-	// read the attributes of the source.
-	var stat syscall.Statfs_t
+	if fi, err := os.Stat(dst); err == nil && fi.IsDir() {
+		dst = filepath.Join(dst, filepath.Base(target)+" alias")
+	}
+	opts := BookmarkOpts{}
+	if targetInfo.IsDir() {
+		opts.TypeCode = darwin.KContainerFolderAliasType
+	}
+	return AliasWithOpts(target, dst, opts)
+}
 
-	err = syscall.Statfs(srcPath, &stat)
+// WriteFileloc writes a Finder location file (.fileloc) to dst pointing to
+// target.
+func WriteFileloc(target, dst string) error {
+	bookmark, err := NewBookmarkData(target)
 	if err != nil {
-		return fmt.Errorf("failed to read the file stats - %s", err)
+		return err
+	}
+	targetPath, err := filepath.Abs(target)
+	if err != nil {
+		return fmt.Errorf("failed to get the path of the target - %s", err)
+	}
+	data, err := encodeFileloc(bookmark, filepath.Clean(targetPath))
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(dst, data, 0644); err != nil {
+		return fmt.Errorf("failed to write the location file - %s", err)
+	}
+	return nil
+}
+
+// AliasWithOpts is like Alias but lets the caller customize how the bookmark
+// is created.
+func AliasWithOpts(src, dst string, opts BookmarkOpts) error {
+	bookmark, err := newBookmark(src, opts)
+	if err != nil {
+		return err
 	}
 
-	// Volume path
-	volPathB := []byte{}
-	for _, b := range stat.Mntonname {
-		if b == 0x00 {
-			break
+	w, err := os.Create(filepath.Clean(dst))
+	if err != nil {
+		return fmt.Errorf("failed to create the file at destination - %s", err)
+	}
+	// don't leave a truncated alias behind
+	if err = bookmark.Write(w); err != nil {
+		w.Close()
+		os.Remove(dst)
+		return fmt.Errorf("failed to write the bookmark - %s", err)
+	}
+	if err = w.Close(); err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("failed to close the alias file - %s", err)
+	}
+	// turn the file into an actual alias by setting the finder flags, merged
+	// with the finder info the file might already have
+	info, err := fileFinderInfo(dst)
+	if err != nil {
+		return err
+	}
+	info.FileType = darwin.KAliasFileType
+	info.FileCreator = darwin.KSystemCreator
+	info.FinderFlags |= darwin.FFKIsAlias
+	if opts.TypeCode != 0 {
+		info.FileType = uint32(opts.TypeCode)
+	}
+	if opts.CreatorCode != 0 {
+		info.FileCreator = uint32(opts.CreatorCode)
+	}
+	if err = darwin.SetFinderInfo(dst, info); err != nil {
+		return fmt.Errorf("failed to flag %s as an alias - %s", dst, err)
+	}
+
+	if opts.IconLocation != nil {
+		if err = setIconLocation(dst, *opts.IconLocation); err != nil {
+			return err
 		}
-		volPathB = append(volPathB, byte(b))
 	}
-	volPath := string(volPathB)
-	fsType := []byte{}
-	for _, b := range stat.Fstypename {
-		if b == 0 {
-			break
+
+	if opts.ClearQuarantine {
+		// the file might not be quarantined
+		if err := darwin.Removexattr(dst, "com.apple.quarantine"); err != nil && err != syscall.ENOATTR {
+			return fmt.Errorf("failed to clear the quarantine of %s - %s", dst, err)
 		}
-		fsType = append(fsType, byte(b))
 	}
-	fileSystemType := string(fsType)
 
-	var volumeAttrs *darwin.AttrList
-	buf := make([]byte, 512)
-	switch fileSystemType {
-	case "hfs":
-		volumeAttrs, err = darwin.GetAttrList(volPath,
-			darwin.AttrListMask{
-				CommonAttr: darwin.ATTR_CMN_CRTIME,
-				VolAttr: darwin.ATTR_VOL_SIZE |
-					darwin.ATTR_VOL_NAME |
-					darwin.ATTR_VOL_UUID,
-			},
-			buf, 0|darwin.FSOPT_REPORT_FULLSIZE)
+	return err
+}
+
+// NewBookmarkData gathers the volume and file attributes of the file found at
+// src and returns its bookmark without writing anything. The bookmark can be
+// inspected or modified before being written.
+func NewBookmarkData(src string) (*BookmarkData, error) {
+	return newBookmark(src, BookmarkOpts{})
+}
+
+// BookmarkTo writes the alias data of the file found at src to w, for
+// instance to store it in an archive. No file is created so, unlike Alias,
+// the Finder alias flag isn't set: w only receives the raw bookmark data.
+func BookmarkTo(src string, w io.Writer) error {
+	bookmark, err := NewBookmarkData(src)
+	if err != nil {
+		return err
+	}
+	return bookmark.Write(w)
+}
+
+// maxAliasChain is the maximum number of aliases followed when the source of
+// an alias is itself an alias.
+const maxAliasChain = 16
+
+// resolveAliasSource returns the path of the file the alias found at path
+// points to, following the chain when the target is an alias too.
+func resolveAliasSource(path string) (string, error) {
+	for i := 0; i < maxAliasChain; i++ {
+		f, err := os.Open(path)
 		if err != nil {
-			log.Printf("failed to retrieve volume attribute list (using blank values) - %s", err)
-			volumeAttrs = &darwin.AttrList{
-				CreationTime: &darwin.TimeSpec{},
-			}
+			return "", fmt.Errorf("failed to open the source alias - %s", err)
 		}
-		//we don't seem to be able to get the vol attributes for other formats such as "exFat"
-	default:
-		volumeAttrs = &darwin.AttrList{
-			VolName:      strings.Replace(volPath, "/Volumes/", "", 1),
-			CreationTime: &darwin.TimeSpec{},
+		b, err := AliasFromReader(f)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to decode the source alias %s - %s", path, err)
 		}
-		if st, err := os.Stat(volPath); err == nil {
-			volumeAttrs.VolSize = st.Size()
+		target, _, err := b.Resolve()
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve the source alias %s - %s", path, err)
 		}
+		isAlias, err := IsAliasErr(target)
+		if err != nil {
+			return "", err
+		}
+		if !isAlias {
+			return target, nil
+		}
+		path = target
+	}
+	return "", fmt.Errorf("the source alias %s is followed by more than %d aliases", path, maxAliasChain)
+}
+
+// newBookmark builds the bookmark of the file found at src.
+func newBookmark(src string, opts BookmarkOpts) (*BookmarkData, error) {
+	srcPath, err := filepath.Abs(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the path of the source - %s", err)
+	}
+	srcPath = filepath.Clean(srcPath)
+
+	bookmark := &BookmarkData{
+		CreationOptions:  uint32(BookmarkCreationSuitableForBookmarkFile),
+		WasFileReference: true,
+		UserName:         "unknown",
+	}
+	if err = bookmark.setVolume(srcPath); err != nil {
+		return nil, err
 	}
 
+	buf := make([]byte, 512)
 	// file attributes
 	fileAttrs, err := darwin.GetAttrList(srcPath,
 		darwin.AttrListMask{
@@ -131,130 +262,218 @@ func Alias(src, dst string) error {
 		},
 		buf, darwin.FSOPT_NOFOLLOW)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve file attribute list - %s", err)
+		return nil, fmt.Errorf("failed to retrieve file attribute list - %s", err)
 	}
 
-	// TODO: decode the source alias and adjust the source instead of failing.
-	// macOS UI lest you create an alias to an alias by reading the alias source
-	// and creating another version of the alias.
+	// like Finder, an alias to an alias points to the target of the source
 	if fileAttrs.FileInfo.FinderFlags&darwin.FFKIsAlias > 0 {
-		return fmt.Errorf("can't safely bookmark to a bookmark, choose another source")
-	}
-
-	w, err := os.Create(filepath.Clean(dst))
-	if err != nil {
-		return fmt.Errorf("failed to create the file at destination - %s", err)
+		if opts.Strict {
+			return nil, fmt.Errorf("can't safely bookmark to a bookmark, choose another source")
+		}
+		target, err := resolveAliasSource(srcPath)
+		if err != nil {
+			return nil, err
+		}
+		return newBookmark(target, opts)
 	}
-	defer w.Close()
 
 	goStat, err := os.Stat(srcPath)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve file id for %s - %s", srcPath, err)
+		return nil, fmt.Errorf("failed to retrieve file id for %s - %s", srcPath, err)
 	}
 	fileStat := goStat.Sys().(*syscall.Stat_t)
 
-	bookmark := &BookmarkData{
-		FileSystemType:     fileSystemType,
-		FileCreationDate:   fileAttrs.CreationTime.Time(),
-		VolumePath:         volPath,
-		VolumeIsRoot:       volPath == "/",
-		VolumeURL:          "file://" + volPath,
-		VolumeName:         volumeAttrs.VolName,
-		VolumeSize:         volumeAttrs.VolSize,
-		VolumeCreationDate: volumeAttrs.CreationTime.Time(),
-		VolumeUUID:         strings.ToUpper(volumeAttrs.StringVolUUID()),
-		VolumeProperties:   []byte{},
-		CreationOptions:    512,
-		WasFileReference:   true,
-		UserName:           "unknown",
-		// CNID:               uint32(fileAttrs.FileID),
-		UID: fileStat.Uid,
-	}
+	bookmark.FileCreationDate = fileAttrs.CreationTime.Time()
+	bookmark.UID = fileStat.Uid
 	if fileStat.Uid > 0 {
 		u, err := user.LookupId(strconv.Itoa(int(fileStat.Uid)))
 		if err == nil {
 			bookmark.UserName = u.Username
 		}
 	}
-
-	// volume properties
-	bb := &bytes.Buffer{}
-	// if bookmark.VolumeIsRoot {
-	// 0x81, 0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0,
-	binary.Write(bb, binary.LittleEndian, uint64(0x81|darwin.KCFURLVolumeSupportsPersistentIDs))
-	// 0xef, 0x13, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0,
-	binary.Write(bb, binary.LittleEndian, uint64(0x13ef|darwin.KCFURLVolumeSupportsPersistentIDs))
-	// } else {
-	// 	binary.Write(bb, binary.LittleEndian, uint64(darwin.KCFURLVolumeIsLocal|darwin.KCFURLVolumeIsExternal))
-	// 	binary.Write(bb, binary.LittleEndian, uint64(0x13ef|darwin.KCFURLVolumeSupportsPersistentIDs))
-	// }
-	bb.Write([]byte{0xef, 0x13, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0})
-	// binary.Write(bb, binary.LittleEndian, uint64(0))
-	bookmark.VolumeProperties = bb.Bytes()
+	if bookmark.UserName == "unknown" {
+		bookmark.Defaulted = append(bookmark.Defaulted, "UserName")
+	}
 
 	// file properties
-	bb2 := &bytes.Buffer{}
-	switch fileAttrs.ObjType {
-	// file
-	case darwin.VREG:
-		binary.Write(bb2, binary.LittleEndian, uint64(darwin.KCFURLResourceIsRegularFile))
-		// folder
-	case darwin.VDIR:
-		binary.Write(bb2, binary.LittleEndian, uint64(darwin.KCFURLResourceIsDirectory))
-		// symlink
-	case darwin.VLNK:
-		binary.Write(bb2, binary.LittleEndian, uint64(darwin.KCFURLResourceIsSymbolicLink))
-	default:
-		binary.Write(bb2, binary.LittleEndian, uint64(darwin.KCFURLResourceIsRegularFile))
-	}
-	bb2.Write([]byte{0x1f, 0x2, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0})
-	bb2.Write([]byte{0x1f, 0x2, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0})
-	// binary.Write(bb, binary.LittleEndian, uint64(0x0f))
-	// binary.Write(bb, binary.LittleEndian, uint64(0))
-	bookmark.FileProperties = bb2.Bytes()
-
-	// getting data about each node of the path
-	relPath, _ := filepath.Rel("/", srcPath)
-	// buf = make([]byte, 256)
-	subPath := srcPath
-
-	// collecting the CNIDs of the entire path
-	bookmark.CNIDPath = []uint64{fileStat.Ino}
-
-	// get the file ID of the containing folder
-	goStat, err = os.Stat(filepath.Dir(subPath))
+	bookmark.FileProperties = setFilePropertyKind(nil, fileAttrs.ObjType)
+
+	// path components and the file id of each of them
+	bookmark.Path = pathComponents(filepath.ToSlash(srcPath))
+	if !opts.SkipCNIDPath {
+		bookmark.CNID = fileAttrs.FileID
+		// collecting the CNIDs of the entire path
+		bookmark.CNIDPath = make([]uint64, len(bookmark.Path))
+		subPath := "/"
+		for i, item := range bookmark.Path {
+			subPath = filepath.Join(subPath, item)
+			goStat, err := os.Stat(subPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to retrieve file id for %s - %s", subPath, err)
+			}
+			bookmark.CNIDPath[i] = goStat.Sys().(*syscall.Stat_t).Ino
+		}
+	}
+
+	// targets at the root of the file system don't have a containing folder
+	// in the path.
+	if len(bookmark.Path) > 1 {
+		bookmark.ContainingFolderIDX = uint32(len(bookmark.Path)) - 2
+	}
+
+	return bookmark, nil
+}
+
+// ConvertSymlink replaces the symlink at the passed path by an alias to its
+// target. The target must exist.
+func ConvertSymlink(path string) error {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s - %s", path, err)
+	}
+	if fi.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("%s isn't a symlink", path)
+	}
+	target, err := os.Readlink(path)
+	if err != nil {
+		return fmt.Errorf("failed to read the symlink %s - %s", path, err)
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+	// create the alias next to the symlink and swap them so the symlink is
+	// kept if the alias can't be created.
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".alias")
+	if err = Alias(target, tmp); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to alias %s - %s", target, err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace the symlink %s - %s", path, err)
+	}
+	return nil
+}
+
+// RelativeAlias writes an alias at dst pointing to targetPath with a URL
+// relative to fromDir. The alias only stores the path of the target (no CNIDs)
+// so targetPath doesn't need to exist, which is useful to generate aliases as
+// build artifacts.
+func RelativeAlias(targetPath, fromDir, dst string) error {
+	bookmark, err := relativeBookmark(targetPath, fromDir)
+	if err != nil {
+		return err
+	}
+	w, err := os.Create(filepath.Clean(dst))
+	if err != nil {
+		return fmt.Errorf("failed to create the file at destination - %s", err)
+	}
+	if err = bookmark.Write(w); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write the bookmark - %s", err)
+	}
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("failed to close the alias file - %s", err)
+	}
+	// turn the file into an actual alias by setting the finder flags
+	return darwin.SetAsAlias(dst)
+}
+
+// SetVolume moves the bookmark to the volume the passed path is on, for
+// instance after its target was copied to another disk. The volume
+// information is read from the new volume and the path of the target
+// relative to its volume is kept. CNIDs only make sense on their volume so
+// they are dropped and the bookmark resolves by path.
+func (b *BookmarkData) SetVolume(volumePath string) error {
+	rel := b.volumeRelativePath()
+	if err := b.setVolume(volumePath); err != nil {
+		return err
+	}
+	b.Path = append(pathComponents(b.VolumePath), rel...)
+	b.CNIDPath = nil
+	b.CNID = 0
+	b.ContainingFolderIDX = 0
+	if len(b.Path) > 1 {
+		b.ContainingFolderIDX = uint32(len(b.Path)) - 2
+	}
+	return nil
+}
+
+// setVolume sets the volume information of the bookmark using the volume the
+// passed path is on.
+func (b *BookmarkData) setVolume(path string) error {
+	// read the attributes of the source.
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return fmt.Errorf("failed to read the file stats - %s", err)
+	}
+
+	volPath := statfsString(stat.Mntonname[:])
+	fileSystemType := statfsString(stat.Fstypename[:])
+
+	// the volume UUID and creation date are usually available on non HFS
+	// volumes too (APFS, exFAT, MS-DOS...), fallback values are only used if
+	// the attributes can't be read at all.
+	var volumeAttrs *darwin.AttrList
+	mask := darwin.AttrListMask{
+		CommonAttr: darwin.ATTR_CMN_CRTIME,
+		VolAttr: darwin.ATTR_VOL_SIZE |
+			darwin.ATTR_VOL_NAME |
+			darwin.ATTR_VOL_MOUNTFLAGS |
+			darwin.ATTR_VOL_UUID,
+	}
+	// the buffer must fit the longest volume name
+	attrs, err := darwin.GetAttrList(volPath, mask,
+		make([]byte, darwin.AttrBufSize(mask)), 0|darwin.FSOPT_REPORT_FULLSIZE)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve file id for %s - %s", filepath.Dir(subPath), err)
+		log.Printf("failed to retrieve the %s volume attribute list (using fallback values) - %s", fileSystemType, err)
+	} else {
+		volumeAttrs = attrs
 	}
-	fileStat = goStat.Sys().(*syscall.Stat_t)
-	bookmark.CNIDPath = append([]uint64{fileStat.Ino}, bookmark.CNIDPath...)
+	b.FileSystemType = fileSystemType
+	// the volume size attribute isn't always available
+	fsSize := int64(stat.Blocks) * int64(stat.Bsize)
+	b.setVolumeAttrs(volPath, stat.Flags, fsSize, volumeAttrs)
 
-	bookmark.Path = []string{filepath.Base(filepath.Dir(subPath)), filepath.Base(subPath)}
+	return nil
+}
 
-	// walk the path and extract the file id of each sub path
-	dir := filepath.Dir(relPath)
-	for dir != "" {
-		dir, _ = filepath.Split(filepath.Clean(dir))
-		if dir == "" {
+// statfsString converts a null terminated string from syscall.Statfs_t.
+func statfsString(s []int8) string {
+	b := []byte{}
+	for _, c := range s {
+		if c == 0 {
 			break
 		}
-
-		bookmark.Path = append([]string{filepath.Base(dir)}, bookmark.Path...)
-		subPath = filepath.Join("/", dir)
-		goStat, err := os.Stat(subPath)
-		if err != nil {
-			return fmt.Errorf("failed to retrieve file id for %s - %s", subPath, err)
-		}
-		fileStat := goStat.Sys().(*syscall.Stat_t)
-		bookmark.CNIDPath = append([]uint64{fileStat.Ino}, bookmark.CNIDPath...)
+		b = append(b, byte(c))
 	}
+	return string(b)
+}
 
-	bookmark.ContainingFolderIDX = uint32(len(bookmark.Path)) - 2
-
-	bookmark.Write(w)
-	w.Close()
-	// turn the file into an actual alias by setting the finder flags
-	darwin.SetAsAlias(dst)
+// fileFinderInfo returns the current finder info of the file.
+func fileFinderInfo(path string) (darwin.FileInfo, error) {
+	buf := make([]byte, 256)
+	attrs, err := darwin.GetAttrList(path,
+		darwin.AttrListMask{
+			CommonAttr: darwin.ATTR_CMN_OBJTYPE | darwin.ATTR_CMN_FNDRINFO,
+		},
+		buf, darwin.FSOPT_NOFOLLOW)
+	if err != nil {
+		return darwin.FileInfo{}, fmt.Errorf("failed to retrieve the finder info of %s - %s", path, err)
+	}
+	return attrs.FileInfo, nil
+}
 
-	return err
+// setIconLocation sets the Finder icon location of the file while preserving
+// the rest of its finder info.
+func setIconLocation(path string, pt image.Point) error {
+	info, err := fileFinderInfo(path)
+	if err != nil {
+		return err
+	}
+	info.Location = darwin.NewPoint(pt)
+	if err = darwin.SetFinderInfo(path, info); err != nil {
+		return fmt.Errorf("failed to set the icon location of %s - %s", path, err)
+	}
+	return nil
 }
@@ -0,0 +1,695 @@
+package cocoa
+
+import (
+	"bytes"
+	"image"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mattetti/cocoa/darwin"
+)
+
+// newTestTarget creates a temporary directory containing a target.txt file.
+// The directory is removed when the test ends and symlinks in its path are
+// resolved so it matches the paths stored in bookmarks.
+func newTestTarget(t testing.TB) (dir, src string) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	// the temp dir usually is behind a symlink (/var -> /private/var)
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		t.Fatal(err)
+	}
+	src = filepath.Join(dir, "target.txt")
+	if err := ioutil.WriteFile(src, []byte("cocoa"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir, src
+}
+
+// newTestAlias is like newTestTarget but also creates an alias to the target
+// named "target alias" in the directory and returns its decoded bookmark.
+func newTestAlias(t testing.TB) (dir, src string, b *BookmarkData) {
+	t.Helper()
+	dir, src = newTestTarget(t)
+	dst := filepath.Join(dir, "target alias")
+	if err := Alias(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if b, err = AliasFromReader(f); err != nil {
+		t.Fatal(err)
+	}
+	return dir, src, b
+}
+
+func TestBookmarkFileInfo(t *testing.T) {
+	dir, src := newTestTarget(t)
+	dst := filepath.Join(dir, "target alias")
+	// file system creation times are stored with a second precision
+	before := time.Now().Add(-time.Second)
+	if err := Alias(src, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := BookmarkFileInfo(dst)
+	if err != nil {
+		t.Fatalf("BookmarkFileInfo() error = %v", err)
+	}
+	if !info.IsAlias {
+		t.Errorf("BookmarkFileInfo().IsAlias = false, want true")
+	}
+	if info.CreationDate.Before(before) || info.CreationDate.After(time.Now()) {
+		t.Errorf("BookmarkFileInfo().CreationDate = %v, expected a time after %v", info.CreationDate, before)
+	}
+}
+
+// isAliasGetAttrList is the getattrlist based implementation IsAlias used to
+// have, kept to compare the behavior and performance.
+func isAliasGetAttrList(path string) bool {
+	fileAttrs, err := darwin.GetAttrList(path,
+		darwin.AttrListMask{
+			CommonAttr: darwin.ATTR_CMN_OBJTYPE | darwin.ATTR_CMN_FNDRINFO,
+		},
+		make([]byte, 256), darwin.FSOPT_NOFOLLOW)
+	if err != nil {
+		return false
+	}
+	return fileAttrs.FileInfo.FinderFlags&darwin.FFKIsAlias > 0
+}
+
+func TestIsAlias(t *testing.T) {
+	dir, src, _ := newTestAlias(t)
+	dst := filepath.Join(dir, "target alias")
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(dst, link); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{dst, true},
+		{src, false},
+		{dir, false},
+		{link, false},
+		{filepath.Join(dir, "missing"), false},
+		{"fixtures/alias", false},
+		{"fixtures/exFATAlias", false},
+	}
+	for _, tt := range tests {
+		if got := IsAlias(tt.path); got != tt.want {
+			t.Errorf("IsAlias(%s) = %t, want %t", tt.path, got, tt.want)
+		}
+		if got := isAliasGetAttrList(tt.path); got != tt.want {
+			t.Errorf("isAliasGetAttrList(%s) = %t, want %t", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRemoveAlias(t *testing.T) {
+	dir, _, _ := newTestAlias(t)
+	dst := filepath.Join(dir, "target alias")
+	before, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveAlias(dst); err != nil {
+		t.Fatalf("RemoveAlias() error = %v", err)
+	}
+	if IsAlias(dst) {
+		t.Error("expected the file to no longer be an alias")
+	}
+	after, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Error("RemoveAlias() modified the file content")
+	}
+	// the type and creator codes are kept
+	info, err := darwin.Getxattr(dst, "com.apple.FinderInfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(info[:8]) != "alisMACS" {
+		t.Errorf("finder info type and creator = %q, want alisMACS", info[:8])
+	}
+
+	if err := RemoveAlias(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected an error removing the alias flag of a missing file")
+	}
+}
+
+func TestIsAliasErr_missingFile(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "cocoa-missing-alias")
+	if _, err := IsAliasErr(path); err == nil {
+		t.Error("expected an error checking a missing file")
+	}
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	if IsAlias(path) {
+		t.Error("IsAlias() = true for a missing file")
+	}
+	if logs.Len() > 0 {
+		t.Errorf("IsAlias() logged %q", logs.String())
+	}
+}
+
+func BenchmarkIsAlias(b *testing.B) {
+	dir, _, _ := newTestAlias(b)
+	dst := filepath.Join(dir, "target alias")
+
+	b.Run("getxattr", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			IsAlias(dst)
+		}
+	})
+	b.Run("getattrlist", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			isAliasGetAttrList(dst)
+		}
+	})
+}
+
+func TestBookmarkTo(t *testing.T) {
+	dir, src := newTestTarget(t)
+	buf := &bytes.Buffer{}
+	if err := BookmarkTo(src, buf); err != nil {
+		t.Fatalf("BookmarkTo() error = %v", err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("expected BookmarkTo not to write any file, found %d files", len(files))
+	}
+	got, err := AliasFromReader(buf)
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if got.TargetPath() != src {
+		t.Errorf("TargetPath() = %s, want %s", got.TargetPath(), src)
+	}
+
+	if err := BookmarkTo(filepath.Join(dir, "missing"), ioutil.Discard); err == nil {
+		t.Error("expected an error for a missing source")
+	}
+}
+
+func TestNewBookmarkData(t *testing.T) {
+	dir, src := newTestTarget(t)
+	b, err := NewBookmarkData(src)
+	if err != nil {
+		t.Fatalf("NewBookmarkData() error = %v", err)
+	}
+	if len(b.Path) == 0 || b.Path[len(b.Path)-1] != "target.txt" {
+		t.Errorf("unexpected bookmark path %v", b.Path)
+	}
+	if len(b.CNIDPath) != len(b.Path) {
+		t.Errorf("expected %d CNIDs, got %v", len(b.Path), b.CNIDPath)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("expected NewBookmarkData not to write any file, found %d files", len(files))
+	}
+
+	b.UserName = "cocoa"
+	buf := &bytes.Buffer{}
+	if err := b.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := AliasFromReader(buf)
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if !got.Equal(b) {
+		t.Errorf("the bookmark didn't round trip, got %v, want %v", got, b)
+	}
+	if got.CNID == 0 || got.CNID != b.CNIDPath[len(b.CNIDPath)-1] {
+		t.Errorf("CNID = %#x, want the file ID of the target", got.CNID)
+	}
+	// the user name is only written for targets on the root volume
+	if got.VolumeIsRoot && got.UserName != "cocoa" {
+		t.Errorf("UserName = %q, want the overridden name", got.UserName)
+	}
+}
+
+func TestAliasWithOpts_skipCNIDPath(t *testing.T) {
+	dir, src := newTestTarget(t)
+	dst := filepath.Join(dir, "target alias")
+	if err := AliasWithOpts(src, dst, BookmarkOpts{SkipCNIDPath: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	b, err := AliasFromReader(f)
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if len(b.CNIDPath) != 0 {
+		t.Errorf("expected a path only bookmark but got the CNID path %v", b.CNIDPath)
+	}
+	if len(b.Path) == 0 || b.Path[len(b.Path)-1] != "target.txt" {
+		t.Errorf("unexpected bookmark path %v", b.Path)
+	}
+}
+
+func TestAliasWithOpts_iconLocation(t *testing.T) {
+	dir, src := newTestTarget(t)
+	dst := filepath.Join(dir, "target alias")
+	if err := AliasWithOpts(src, dst, BookmarkOpts{IconLocation: &image.Point{X: 120, Y: 42}}); err != nil {
+		t.Fatal(err)
+	}
+
+	attrs, err := darwin.GetAttrList(dst,
+		darwin.AttrListMask{CommonAttr: darwin.ATTR_CMN_OBJTYPE | darwin.ATTR_CMN_FNDRINFO},
+		make([]byte, 256), darwin.FSOPT_NOFOLLOW)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc := attrs.FileInfo.Location; loc.X != 120 || loc.Y != 42 {
+		t.Errorf("expected the icon location to be 120x42, got %dx%d", loc.X, loc.Y)
+	}
+	if attrs.FileInfo.FinderFlags&darwin.FFKIsAlias == 0 {
+		t.Error("expected the alias flag to be preserved")
+	}
+}
+
+func TestAliasWithOpts_typeCreatorCodes(t *testing.T) {
+	dir, src := newTestTarget(t)
+	typeCode, _ := darwin.ParseFourCharCode("SDal")
+	creatorCode, _ := darwin.ParseFourCharCode("SDcr")
+	tests := []struct {
+		name         string
+		opts         BookmarkOpts
+		typ, creator darwin.FourCharCode
+	}{
+		{"default", BookmarkOpts{}, darwin.KAliasFileType, darwin.KSystemCreator},
+		{"custom", BookmarkOpts{TypeCode: typeCode, CreatorCode: creatorCode}, typeCode, creatorCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := filepath.Join(dir, tt.name+" alias")
+			if err := AliasWithOpts(src, dst, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			attrs, err := darwin.GetAttrList(dst,
+				darwin.AttrListMask{CommonAttr: darwin.ATTR_CMN_FNDRINFO},
+				make([]byte, 256), darwin.FSOPT_NOFOLLOW)
+			if err != nil {
+				t.Fatal(err)
+			}
+			info := attrs.FileInfo
+			if darwin.FourCharCode(info.FileType) != tt.typ || darwin.FourCharCode(info.FileCreator) != tt.creator {
+				t.Errorf("type/creator = %s/%s, want %s/%s", darwin.FourCharCode(info.FileType), darwin.FourCharCode(info.FileCreator), tt.typ, tt.creator)
+			}
+			if info.FinderFlags&darwin.FFKIsAlias == 0 {
+				t.Error("expected the alias flag to be set")
+			}
+		})
+	}
+}
+
+func TestAliasWithOpts_existingFinderInfo(t *testing.T) {
+	dir, src := newTestTarget(t)
+	dst := filepath.Join(dir, "target alias")
+	if err := ioutil.WriteFile(dst, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := darwin.SetFinderInfo(dst, darwin.FileInfo{FinderFlags: darwin.FFKIsInvisible}); err != nil {
+		t.Fatal(err)
+	}
+	typeCode, _ := darwin.ParseFourCharCode("SDal")
+	if err := AliasWithOpts(src, dst, BookmarkOpts{TypeCode: typeCode}); err != nil {
+		t.Fatal(err)
+	}
+	attrs, err := darwin.GetAttrList(dst,
+		darwin.AttrListMask{CommonAttr: darwin.ATTR_CMN_FNDRINFO},
+		make([]byte, 256), darwin.FSOPT_NOFOLLOW)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := attrs.FileInfo
+	if darwin.FourCharCode(info.FileType) != typeCode {
+		t.Errorf("type = %s, want %s", darwin.FourCharCode(info.FileType), typeCode)
+	}
+	if info.FinderFlags&darwin.FFKIsInvisible == 0 {
+		t.Error("expected the existing finder flags to be preserved")
+	}
+	if info.FinderFlags&darwin.FFKIsAlias == 0 {
+		t.Error("expected the alias flag to be set")
+	}
+}
+
+func TestAliasWithOpts_clearQuarantine(t *testing.T) {
+	dir, src := newTestTarget(t)
+	dst := filepath.Join(dir, "target alias")
+	if err := ioutil.WriteFile(dst, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command("xattr", "-w", "com.apple.quarantine", "0081;5b63c0a4;Safari;", dst).CombinedOutput(); err != nil {
+		t.Skipf("failed to quarantine the file - %s %s", err, out)
+	}
+
+	if err := AliasWithOpts(src, dst, BookmarkOpts{ClearQuarantine: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := exec.Command("xattr", "-p", "com.apple.quarantine", dst).Run(); err == nil {
+		t.Error("expected the quarantine to be cleared")
+	}
+	if !IsAlias(dst) {
+		t.Error("expected the destination to be an alias")
+	}
+	// not quarantined
+	if err := AliasWithOpts(src, dst, BookmarkOpts{ClearQuarantine: true}); err != nil {
+		t.Errorf("AliasWithOpts() error = %v", err)
+	}
+}
+
+func TestAlias_aliasSource(t *testing.T) {
+	dir, src, _ := newTestAlias(t)
+	alias := filepath.Join(dir, "target alias")
+
+	dst := filepath.Join(dir, "target alias alias")
+	if err := Alias(alias, dst); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	b, err := AliasFromReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.TargetPath(); got != src {
+		t.Errorf("TargetPath() = %s, want %s", got, src)
+	}
+
+	record, err := NewAliasRecord(alias)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Path != src {
+		t.Errorf("NewAliasRecord().Path = %s, want %s", record.Path, src)
+	}
+
+	err = AliasWithOpts(alias, filepath.Join(dir, "strict alias"), BookmarkOpts{Strict: true})
+	if err == nil {
+		t.Error("expected an error aliasing an alias in strict mode")
+	}
+	if _, err = NewAliasRecordWithOpts(alias, BookmarkOpts{Strict: true}); err == nil {
+		t.Error("expected an error building the alias record of an alias in strict mode")
+	}
+}
+
+func TestAlias_longComponentName(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	longDir := filepath.Join(dir, strings.Repeat("d", 250))
+	if err := os.Mkdir(longDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(longDir, strings.Repeat("f", 250)+".wav")
+	if err := ioutil.WriteFile(src, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "long alias")
+	if err := Alias(src, dst); err != nil {
+		t.Fatalf("Alias() error = %v", err)
+	}
+
+	f, err := os.Open(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	b, err := AliasFromReader(f)
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if b.TargetPath() != src {
+		t.Errorf("TargetPath() = %s, want %s", b.TargetPath(), src)
+	}
+	if len(b.CNIDPath) != len(b.Path) {
+		t.Errorf("got %d CNIDs for %d path components", len(b.CNIDPath), len(b.Path))
+	}
+}
+
+func TestCreateAliasFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "file.wav")
+	if err := ioutil.WriteFile(file, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	folder := filepath.Join(dir, "folder")
+	if err := os.Mkdir(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	aliases := filepath.Join(dir, "aliases")
+	if err := os.Mkdir(aliases, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target   string
+		dst      string
+		want     string
+		fileType uint32
+	}{
+		{file, aliases, filepath.Join(aliases, "file.wav alias"), darwin.KAliasFileType},
+		{folder, filepath.Join(aliases, "my folder"), filepath.Join(aliases, "my folder"), darwin.KContainerFolderAliasType},
+	}
+	for _, tt := range tests {
+		if err := CreateAliasFile(tt.target, tt.dst); err != nil {
+			t.Fatalf("CreateAliasFile(%s) error = %v", tt.target, err)
+		}
+		if !IsAlias(tt.want) {
+			t.Fatalf("expected %s to be an alias", tt.want)
+		}
+		attrs, err := darwin.GetAttrList(tt.want,
+			darwin.AttrListMask{CommonAttr: darwin.ATTR_CMN_FNDRINFO},
+			make([]byte, 256), darwin.FSOPT_NOFOLLOW)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if attrs.FileInfo.FileType != tt.fileType || attrs.FileInfo.FileCreator != darwin.KSystemCreator {
+			t.Errorf("type/creator = %#x/%#x, want %#x/%#x", attrs.FileInfo.FileType, attrs.FileInfo.FileCreator, tt.fileType, darwin.KSystemCreator)
+		}
+
+		f, err := os.Open(tt.want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := AliasFromReader(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("AliasFromReader() error = %v", err)
+		}
+		if b.TargetPath() != tt.target {
+			t.Errorf("TargetPath() = %s, want %s", b.TargetPath(), tt.target)
+		}
+	}
+}
+
+func TestRelativeAlias(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the target doesn't need to exist
+	target := filepath.Join(dir, "assets", "logo.png")
+	dst := filepath.Join(dir, "logo alias")
+	if err := RelativeAlias(target, filepath.Join(dir, "bin"), dst); err != nil {
+		t.Fatal(err)
+	}
+	if !IsAlias(dst) {
+		t.Fatal("expected the destination to be flagged as an alias")
+	}
+
+	f, err := os.Open(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	b, err := AliasFromReader(f)
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if !b.VolumeURLIsRelative || b.VolumeURL != "../assets/logo.png" {
+		t.Errorf("unexpected URL %q (relative: %t)", b.VolumeURL, b.VolumeURLIsRelative)
+	}
+}
+
+func TestAlias_volumeRootTarget(t *testing.T) {
+	// needs a writable volume root
+	var src string
+	roots := []string{"/"}
+	vols, _ := ioutil.ReadDir("/Volumes")
+	for _, fi := range vols {
+		roots = append(roots, filepath.Join("/Volumes", fi.Name()))
+	}
+	for _, root := range roots {
+		f, err := ioutil.TempFile(root, "cocoa")
+		if err == nil {
+			f.Close()
+			src = f.Name()
+			break
+		}
+	}
+	if src == "" {
+		t.Skip("no writable volume root")
+	}
+	defer os.Remove(src)
+
+	dir, err := ioutil.TempDir("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dst := filepath.Join(dir, "root alias")
+	if err := Alias(src, dst); err != nil {
+		t.Fatalf("Alias() error = %v", err)
+	}
+	f, err := os.Open(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	b, err := AliasFromReader(f)
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if int(b.ContainingFolderIDX) >= len(b.Path) {
+		t.Errorf("ContainingFolderIDX = %d is out of the %d path components", b.ContainingFolderIDX, len(b.Path))
+	}
+	if len(b.Path) != len(b.CNIDPath) {
+		t.Errorf("%d path components but %d CNIDs", len(b.Path), len(b.CNIDPath))
+	}
+	if len(b.Path) == 0 || b.Path[len(b.Path)-1] != filepath.Base(src) {
+		t.Errorf("Path = %v, expected it to end with %s", b.Path, filepath.Base(src))
+	}
+
+	if _, err := NewAliasRecord(src); err != nil {
+		t.Errorf("NewAliasRecord() error = %v", err)
+	}
+}
+
+func TestBookmarkData_setVolume_size(t *testing.T) {
+	// df -k reports the size in 1024 byte blocks
+	out, err := exec.Command("df", "-k", "/").Output()
+	if err != nil {
+		t.Skipf("failed to run df - %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 2 {
+		t.Fatalf("unexpected df output %q", out)
+	}
+	blocks, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		t.Fatalf("unexpected df output %q - %s", out, err)
+	}
+
+	b := &BookmarkData{}
+	if err := b.setVolume("/"); err != nil {
+		t.Fatal(err)
+	}
+	if b.VolumeSize == 0 {
+		t.Fatalf("expected the root volume size to be recorded, defaulted fields: %v", b.Defaulted)
+	}
+	if b.VolumeSize/1024 != blocks {
+		t.Errorf("VolumeSize = %d (%d blocks), df reports %d blocks", b.VolumeSize, b.VolumeSize/1024, blocks)
+	}
+}
+
+func TestBookmarkData_SetVolume_sameVolume(t *testing.T) {
+	_, src, b := newTestAlias(t)
+	if err := b.SetVolume(src); err != nil {
+		t.Fatalf("SetVolume() error = %v", err)
+	}
+	if b.TargetPath() != src {
+		t.Errorf("TargetPath() = %s, want %s", b.TargetPath(), src)
+	}
+	defaulted := append([]string{}, b.Defaulted...)
+	if err := b.SetVolume(src); err != nil {
+		t.Fatalf("SetVolume() error = %v", err)
+	}
+	if b.TargetPath() != src {
+		t.Errorf("TargetPath() = %s after a second call, want %s", b.TargetPath(), src)
+	}
+	if !reflect.DeepEqual(b.Defaulted, defaulted) {
+		t.Errorf("Defaulted = %v after a second call, want %v", b.Defaulted, defaulted)
+	}
+}
+
+func TestBookmarkData_SetVolume(t *testing.T) {
+	// needs a writable external volume
+	var extVol string
+	vols, _ := ioutil.ReadDir("/Volumes")
+	for _, fi := range vols {
+		path := filepath.Join("/Volumes", fi.Name())
+		var stat syscall.Statfs_t
+		if err := syscall.Statfs(path, &stat); err != nil || stat.Flags&darwin.MNT_RDONLY > 0 {
+			continue
+		}
+		if statfsString(stat.Mntonname[:]) == path {
+			extVol = path
+			break
+		}
+	}
+	if extVol == "" {
+		t.Skip("no writable external volume mounted")
+	}
+
+	_, _, b := newTestAlias(t)
+	rel := b.volumeRelativePath()
+
+	if err := b.SetVolume(extVol); err != nil {
+		t.Fatalf("SetVolume() error = %v", err)
+	}
+	if b.VolumePath != extVol || b.VolumeIsRoot {
+		t.Errorf("VolumePath = %s (root: %t), want %s", b.VolumePath, b.VolumeIsRoot, extVol)
+	}
+	if want := append(pathComponents(extVol), rel...); !reflect.DeepEqual(b.Path, want) {
+		t.Errorf("Path = %v, want %v", b.Path, want)
+	}
+	if want := filepath.Join(append([]string{extVol}, rel...)...); b.TargetPath() != want {
+		t.Errorf("TargetPath() = %s, want %s", b.TargetPath(), want)
+	}
+	if len(b.CNIDPath) != 0 {
+		t.Errorf("expected the CNIDs to be dropped, got %v", b.CNIDPath)
+	}
+}
@@ -0,0 +1,412 @@
+package cocoa
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mattetti/cocoa/darwin"
+)
+
+// AliasFromReader takes an io.reader pointing to an alias file
+// decodes it and returns the contained bookmark data.
+func AliasFromReader(r io.Reader) (*BookmarkData, error) {
+	return AliasFromReaderWithOpts(r, ParseOptions{})
+}
+
+// IsBookmark reports whether the file at src starts with the "book" and
+// "mark" magic of bookmark data. Unlike IsAlias it doesn't look at the Finder
+// flags and works with bookmark data saved to regular files. false is
+// returned if the file can't be read.
+func IsBookmark(src string) bool {
+	f, err := os.Open(src)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	magic := make([]byte, 12)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return false
+	}
+	return string(magic[:4]) == "book" && string(magic[8:]) == "mark"
+}
+
+// AliasFromReaderWithOpts is like AliasFromReader but lets the caller
+// customize how the bookmark data is parsed.
+func AliasFromReaderWithOpts(r io.Reader, opts ParseOptions) (*BookmarkData, error) {
+	d, err := newBookmarkDecoder(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source - %s", err)
+	}
+	return d.decode(opts)
+}
+
+// BookmarkFromReaderAt decodes the size bytes of alias data found at the
+// start of r. Unlike AliasFromReader the data isn't buffered, the decoder
+// jumps to the entries via their offsets and only reads what it needs, which
+// is useful with bookmarks embedded in large container files. Use an
+// io.NewSectionReader to decode bookmark data starting further in r.
+func BookmarkFromReaderAt(r io.ReaderAt, size int64) (*BookmarkData, error) {
+	d := &bookmarkDecoder{
+		r: io.NewSectionReader(r, 0, size),
+		b: &BookmarkData{},
+	}
+	return d.decode(ParseOptions{})
+}
+
+// decode decodes the alias header, the TOC and the entries it references.
+func (d *bookmarkDecoder) decode(opts ParseOptions) (*BookmarkData, error) {
+	if err := d.aliasHeader(); err != nil {
+		return nil, err
+	}
+	if err := d.seekTOC(); err != nil {
+		return nil, err
+	}
+	if err := d.toc(); err != nil {
+		return nil, fmt.Errorf("failed to read the TOC - %s", err)
+	}
+
+	// we now need to use the oMap to extract the data
+	d.b.tocKeys = d.oMap.keys()
+	for _, key := range d.b.tocKeys {
+		if err := d.decodeEntry(key, d.oMap[key]); err != nil {
+			if !opts.ContinueOnError {
+				d.err = err
+				return d.b, d.err
+			}
+			d.b.DecodeErrors = append(d.b.DecodeErrors, err)
+			// reset the error so the next entries can be read
+			d.err = nil
+		}
+	}
+
+	d.b.IsMinimal = d.b.CreationOptionFlags().IsMinimal()
+
+	// a malformed bookmark might not have any path components in which case
+	// the containing folder index can't point to anything.
+	if len(d.b.Path) == 0 {
+		d.b.ContainingFolderIDX = 0
+	}
+
+	if opts.ValidateUTF8 {
+		if err := validateUTF8(d.b); err != nil {
+			return d.b, err
+		}
+	}
+
+	return d.b, d.err
+}
+
+// decodeEntry decodes the TOC entry found at the passed offset.
+func (d *bookmarkDecoder) decodeEntry(key uint32, offset int) error {
+	// keep the raw record of every entry
+	d.seek(int64(offset), io.SeekStart)
+	raw, err := d.decodeRaw()
+	if err != nil {
+		return fmt.Errorf("failed to read the entry %#x - %s", key, err)
+	}
+	if d.b.rawEntries == nil {
+		d.b.rawEntries = map[uint32][]byte{}
+	}
+	d.b.rawEntries[key] = raw
+
+	switch key {
+	case KBookmarkPath:
+		if Debug {
+			fmt.Println("Parsing path at offset", offset)
+		}
+		// path
+		d.seek(int64(offset), io.SeekStart)
+		d.b.Path, err = d.decodeStringSlice()
+		if err != nil {
+			return fmt.Errorf("failed to decode the file path - %s", err)
+		}
+	case KBookmarkCNIDPath:
+		if Debug {
+			fmt.Println("Parsing CNID path at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		offsets, err := d.decodeUint32Slice()
+		if err != nil {
+			return fmt.Errorf("failed to decode the CNID path offsets - %s", err)
+		}
+		d.b.CNIDPath = make([]uint64, len(offsets))
+		var inode int64
+		for i, offset := range offsets {
+			d.seek(int64(d.headerSize+offset), io.SeekStart)
+			inode, err = d.decodeInt64()
+			if err != nil {
+				return fmt.Errorf("failed to read the %d CNID path in array - %v", i, err)
+			}
+			d.b.CNIDPath[i] = uint64(inode)
+		}
+
+	case KBookmarkVolumeProperties:
+		if Debug {
+			fmt.Println("Parsing volume properties at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		d.b.VolumeProperties, err = d.decodeBytes()
+		if err != nil {
+			return fmt.Errorf("failed to decode the volume properties - %s", err)
+		}
+	case KBookmarkFileProperties:
+		if Debug {
+			fmt.Println("Parsing file properties at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		d.b.FileProperties, err = d.decodeBytes()
+		if err != nil {
+			return fmt.Errorf("failed to decode the file properties - %s", err)
+		}
+	case KBookmarkContainingFolder:
+		if Debug {
+			fmt.Println("Parsing containing folder index at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		// stored as a 32 or 64 bit integer depending on the producer
+		d.b.ContainingFolderIDX, err = d.decodeIndex()
+		if err != nil {
+			return fmt.Errorf("failed to decode the containing folder IDX - %s", err)
+		}
+	case KBookmarkCreationOptions:
+		if Debug {
+			fmt.Println("Parsing creation options at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		d.b.CreationOptions, err = d.decodeUint32()
+		if err != nil {
+			return fmt.Errorf("failed to decode the creation options - %s", err)
+		}
+	case KBookmarkFileCreationDate:
+		if Debug {
+			fmt.Println("Parsing file creation date at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		d.b.FileCreationDate, err = d.decodeTime()
+		if err != nil {
+			return fmt.Errorf("failed to decode the file creation date - %s", err)
+		}
+	case KBookmarkFileID:
+		if Debug {
+			fmt.Println("Parsing file id at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		d.b.CNID, err = d.decodeUint64()
+		if err != nil {
+			return fmt.Errorf("failed to decode the file CNID - %s", err)
+		}
+	case KBookmarkVolumeURL:
+		if Debug {
+			fmt.Println("Parsing volume URL at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		d.b.VolumeURL, d.b.VolumeURLIsRelative, err = d.decodeURL()
+		if err != nil {
+			return fmt.Errorf("failed to decode the volume url - %s", err)
+		}
+	case KBookmarkFileType:
+		if Debug {
+			fmt.Println("Parsing type data at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		d.b.TypeData, err = d.decodeBytes()
+		if err != nil {
+			return fmt.Errorf("failed to decode the type data - %s", err)
+		}
+	case KBookmarkVolumeMountPoint:
+		if Debug {
+			fmt.Println("Parsing volume mount point at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		d.b.VolumeMountPoint, _, err = d.decodeURL()
+		if err != nil {
+			return fmt.Errorf("failed to decode the volume mount point - %s", err)
+		}
+	case KBookmarkVolumeBookmark:
+		if Debug {
+			fmt.Println("Parsing embedded volume bookmark at offset", offset)
+		}
+		// the embedded bookmark can also be stored in another TOC. The
+		// entries of the chained TOCs are merged into this bookmark (the
+		// first TOC wins) instead of being extracted as a separate bookmark,
+		// such references are kept as unknown.
+		if binary.LittleEndian.Uint32(raw[4:])&bmk_data_type_mask != bmk_data {
+			d.keepUnknown(key, raw)
+			break
+		}
+		d.seek(int64(offset), io.SeekStart)
+		d.b.EmbeddedVolumeBookmark, err = d.decodeBytes()
+		if err != nil {
+			return fmt.Errorf("failed to decode the embedded volume bookmark - %s", err)
+		}
+	case KBookmarkURLLengths:
+		if Debug {
+			fmt.Println("Parsing URL lengths at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		offsets, err := d.decodeUint32Slice()
+		if err != nil {
+			return fmt.Errorf("failed to decode the URL lengths offsets - %s", err)
+		}
+		d.b.URLLengths = make([]uint32, len(offsets))
+		for i, offset := range offsets {
+			d.seek(int64(d.headerSize+offset), io.SeekStart)
+			if d.b.URLLengths[i], err = d.decodeUint32(); err != nil {
+				return fmt.Errorf("failed to read the %d URL length in array - %v", i, err)
+			}
+		}
+	case KBookmarkVolumeName:
+		if Debug {
+			fmt.Println("Parsing volume name at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		d.b.VolumeName, err = d.decodeString()
+		if err != nil {
+			return fmt.Errorf("failed to decode the volume name - %s", err)
+		}
+	case KBookmarkVolumePath:
+		if Debug {
+			fmt.Println("Parsing volume path at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		d.b.VolumePath, err = d.decodeString()
+		if err != nil {
+			return fmt.Errorf("failed to decode the volume path - %s", err)
+		}
+	case KBookmarkFullFileName:
+		if Debug {
+			fmt.Println("Parsing filename at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		d.b.Filename, err = d.decodeString()
+		if err != nil {
+			return fmt.Errorf("failed to decode the full filename - %s", err)
+		}
+	case KBookmarkUserName:
+		if Debug {
+			fmt.Println("Parsing username at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		d.b.UserName, err = d.decodeString()
+		if err != nil {
+			return fmt.Errorf("failed to decode the user name - %s", err)
+		}
+	case KBookmarkVolumeSize:
+		if Debug {
+			fmt.Println("Parsing volume size at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		d.b.VolumeSize, err = d.decodeInt64()
+		if err != nil {
+			return fmt.Errorf("failed to decode the volume size - %s", err)
+		}
+	case KBookmarkUID:
+		if Debug {
+			fmt.Println("Parsing UID at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		d.b.UID, err = d.decodeUint32()
+		if err != nil {
+			return fmt.Errorf("failed to decode the UID - %s", err)
+		}
+	case KBookmarkVolumeUUID:
+		if Debug {
+			fmt.Println("Parsing volume UUID at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		// usually stored as a string but some producers use a UUID record
+		if binary.LittleEndian.Uint32(raw[4:])&bmk_data_type_mask == bmk_uuid {
+			var uuid [16]byte
+			uuid, err = d.decodeUUID()
+			d.b.VolumeUUID = uuidString(uuid)
+		} else {
+			d.b.VolumeUUID, err = d.decodeString()
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decode the volume uuid - %s", err)
+		}
+		if isBlankUUID(d.b.VolumeUUID) {
+			d.b.VolumeUUID = ""
+		}
+	case KBookmarkVolumeCreationDate:
+		if Debug {
+			fmt.Println("Parsing creation date at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		d.b.VolumeCreationDate, err = d.decodeTime()
+		if err != nil {
+			return fmt.Errorf("failed to decode the volume creation date - %s", err)
+		}
+		// volumes without a readable creation date get the darwin or unix
+		// epoch, report them as unknown.
+		if d.b.VolumeCreationDate.Equal(darwin.Epoch) || d.b.VolumeCreationDate.Equal(time.Unix(0, 0)) {
+			d.b.VolumeCreationDate = time.Time{}
+		}
+	case KBookmarkVolumeIsRoot:
+		if Debug {
+			fmt.Println("Parsing volume root status at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		d.b.VolumeIsRoot, err = d.decodeBool()
+		if err != nil {
+			return fmt.Errorf("failed to decode the volume root status - %s", err)
+		}
+	case KBookmarkWasFileReference:
+		if Debug {
+			fmt.Println("Parsing file reference at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		d.b.WasFileReference, err = d.decodeBool()
+		if err != nil {
+			return fmt.Errorf("failed to decode the file reference status - %s", err)
+		}
+	case KBookmarkSecurityExtension:
+		if Debug {
+			fmt.Println("Parsing security extension at offset", offset)
+		}
+		d.seek(int64(offset), io.SeekStart)
+		d.b.SecurityExtension, err = d.decodeBytes()
+		if err != nil {
+			return fmt.Errorf("failed to decode the security extension - %s", err)
+		}
+	default:
+		if Debug {
+			fmt.Fprintf(os.Stderr, "%s not parsed\n", BookmarkKeyName(key))
+		}
+		d.keepUnknown(key, raw)
+		// dictionaries can't be written back, at least expose their content.
+		// The raw entry is kept either way so a dictionary that can't be
+		// decoded doesn't fail the whole bookmark.
+		if binary.LittleEndian.Uint32(raw[4:])&bmk_data_type_mask == bmk_dict {
+			d.seek(int64(offset), io.SeekStart)
+			dict, err := d.decodeDict(0)
+			if err != nil {
+				if Debug {
+					fmt.Fprintf(os.Stderr, "failed to decode the dictionary %#x - %s\n", key, err)
+				}
+				d.err = nil
+				return nil
+			}
+			if d.b.Extras == nil {
+				d.b.Extras = map[uint32]interface{}{}
+			}
+			d.b.Extras[key] = dict
+		}
+	}
+	if err == nil && d.err != nil {
+		err = fmt.Errorf("failed to decode %#x - %s", key, d.err)
+	}
+	return err
+}
+
+// keepUnknown keeps the raw record of an entry the decoder doesn't handle so
+// it can be written back as is.
+func (d *bookmarkDecoder) keepUnknown(key uint32, raw []byte) {
+	if d.b.Unknown == nil {
+		d.b.Unknown = map[uint32][]byte{}
+	}
+	d.b.Unknown[key] = raw
+}
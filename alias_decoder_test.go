@@ -0,0 +1,814 @@
+package cocoa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAliasFromReader(t *testing.T) {
+	// fileProperties is the same in both fixtures: a regular file
+	// (KCFURLResourceIsRegularFile) and the mask of the known properties.
+	fileProperties := []uint8{0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+		0x1f, 0x2, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+		0x1f, 0x2, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0}
+	// wavTypeData is the type data of a .wav file, the byte following the
+	// '????' type code differs between the fixtures.
+	wavTypeData := func(flag byte) []byte {
+		return []byte{0x64, 0x6e, 0x69, 0x62, 0x0, 0x0, 0x0, 0x0,
+			0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+			0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+			0x3, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+			'w', 'a', 'v', '?', '?', '?', '?', flag,
+			0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0}
+	}
+
+	tests := []struct {
+		name       string
+		input      string
+		want       *BookmarkData
+		targetPath string
+		// keys of the entries kept undecoded in Unknown
+		unknownKeys []uint32
+	}{
+		{name: "normal alias",
+			input: "fixtures/alias",
+			want: &BookmarkData{
+				Path: []string{"Users", "mattetti", "Downloads", "3bdc4314e98d2e3a39d9c84443129896f30c2dcf7f99c3aec92f577315916a38.wav"},
+				// inode numbers of each path component
+				CNIDPath:            []uint64{0x669dc, 0x9b7c3, 0x26064a, 0x7d30a9},
+				FileCreationDate:    time.Date(2017, time.August, 31, 17, 52, 43, 0, time.UTC),
+				FileProperties:      fileProperties,
+				TypeData:            wavTypeData(0),
+				ContainingFolderIDX: 2,
+				VolumePath:          "/",
+				VolumeIsRoot:        true,
+				VolumeURL:           "file:///",
+				VolumeName:          "Macintosh HD",
+				VolumeSize:          999116767232,
+				VolumeCreationDate:  time.Date(2017, time.May, 1, 21, 28, 17, 0, time.UTC),
+				VolumeUUID:          "3F9E4285-5210-3C1A-BEAE-F7E573866D85",
+				// root volume: local, internal, supports persistent IDs
+				VolumeProperties: []uint8{0x81, 0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0,
+					0xef, 0x13, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0,
+					0xef, 0x13, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0},
+				CreationOptions:  1024,
+				WasFileReference: true,
+				UserName:         "mattetti",
+				UID:              501,
+				Filename:         "3bdc4314e98d2e3a39d9c84443129896f30c2dcf7f99c3aec92f577315916a38.wav",
+			},
+			targetPath:  "/Users/mattetti/Downloads/3bdc4314e98d2e3a39d9c84443129896f30c2dcf7f99c3aec92f577315916a38.wav",
+			unknownKeys: []uint32{KBookmarkUnknown, KBookmarkUnknown1, KBookmarkUnknown2},
+		},
+		{name: "ExtFat alias",
+			input: "fixtures/exFATAlias",
+			want: &BookmarkData{
+				Path: []string{"Volumes", "MattSplice", "file.wav"},
+				// exFAT doesn't have persistent inode numbers, only the
+				// Volumes folder (on the root volume) has a real one.
+				CNIDPath:         []uint64{0x669e0, 0x6010000000c, 0x6010000000c},
+				FileCreationDate: time.Date(2017, time.August, 31, 17, 52, 43, 0, time.UTC),
+				FileProperties:   fileProperties,
+				TypeData:         wavTypeData(1),
+				VolumePath:       "/Volumes/MattSplice",
+				VolumeURL:        "file:///Volumes/MattSplice/",
+				VolumeName:       "MattSplice",
+				VolumeSize:       480085278720,
+				// VolumeCreationDate is unknown on exFAT and stays zero
+				VolumeUUID: "4D0BBFF1-BB47-37DC-A974-6B23EF9E52DD",
+				// external volume: local, removable, supports persistent IDs
+				VolumeProperties: []uint8{0x1, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+					0xef, 0x13, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0,
+					0xef, 0x13, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0},
+				CreationOptions:  1024,
+				WasFileReference: true,
+				// no user name or UID are stored for files on exFAT
+				Filename: "file.wav",
+			},
+			targetPath:  "/Volumes/MattSplice/file.wav",
+			unknownKeys: []uint32{KBookmarkUnknown, KBookmarkUnknown1, KBookmarkUnknown2, KBookmarkTOCPath},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			got, err := AliasFromReader(f)
+			if err != nil {
+				t.Fatalf("AliasFromReader() error = %v", err)
+			}
+			if len(got.DecodeErrors) > 0 {
+				t.Errorf("AliasFromReader().DecodeErrors = %v, expected none", got.DecodeErrors)
+			}
+			if keys := got.unknownKeys(); !reflect.DeepEqual(keys, tt.unknownKeys) {
+				t.Errorf("AliasFromReader().Unknown keys = %#x, want %#x", keys, tt.unknownKeys)
+			}
+			got.FileCreationDate = got.FileCreationDate.UTC()
+			got.VolumeCreationDate = got.VolumeCreationDate.UTC()
+
+			gotV := reflect.ValueOf(*got)
+			wantV := reflect.ValueOf(*tt.want)
+			for i := 0; i < gotV.NumField(); i++ {
+				field := gotV.Type().Field(i)
+				if field.PkgPath != "" || field.Name == "Unknown" || field.Name == "DecodeErrors" {
+					continue
+				}
+				if !reflect.DeepEqual(gotV.Field(i).Interface(), wantV.Field(i).Interface()) {
+					t.Errorf("AliasFromReader().%s = %#v, want %#v", field.Name, gotV.Field(i).Interface(), wantV.Field(i).Interface())
+				}
+			}
+			if got.TargetPath() != tt.targetPath {
+				t.Errorf("AliasFromReader().TargetPath() = %v, want %v", got.TargetPath(), tt.targetPath)
+			}
+		})
+	}
+}
+
+func TestIsBookmark(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	short := dir + "/short"
+	if err := ioutil.WriteFile(short, []byte("book"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"fixtures/alias", true},
+		{"fixtures/exFATAlias", true},
+		{"fixtures/file.fileloc", false},
+		{short, false},
+		{dir + "/missing", false},
+	}
+	for _, tt := range tests {
+		if got := IsBookmark(tt.path); got != tt.want {
+			t.Errorf("IsBookmark(%s) = %t, want %t", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestBookmarkFromReaderAt(t *testing.T) {
+	for _, path := range []string{"fixtures/alias", "fixtures/exFATAlias"} {
+		t.Run(path, func(t *testing.T) {
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := AliasFromReader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// embedded in a larger container
+			container := append(append(bytes.Repeat([]byte{0xff}, 100), data...), bytes.Repeat([]byte{0xff}, 100)...)
+			got, err := BookmarkFromReaderAt(io.NewSectionReader(bytes.NewReader(container), 100, int64(len(data))), int64(len(data)))
+			if err != nil {
+				t.Fatalf("BookmarkFromReaderAt() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("BookmarkFromReaderAt() = %#v, want %#v", got, want)
+			}
+			if _, err := BookmarkFromReaderAt(bytes.NewReader(data), int64(len(data))/2); err == nil {
+				t.Error("expected an error decoding a truncated bookmark")
+			}
+		})
+	}
+}
+
+func TestAliasFromReader_fullFileName(t *testing.T) {
+	encoded := &bytes.Buffer{}
+	b := &BookmarkData{
+		Path:       []string{"Users", "mattetti", "file.wav"},
+		VolumePath: "/",
+		VolumeURL:  "file:///",
+	}
+	if err := b.Write(encoded); err != nil {
+		t.Fatal(err)
+	}
+	inputs := map[string][]byte{"encoded": encoded.Bytes()}
+	for _, path := range []string{"fixtures/alias", "fixtures/exFATAlias"} {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs[path] = data
+	}
+
+	for name, data := range inputs {
+		t.Run(name, func(t *testing.T) {
+			// the file name offset must be the offset of the last path item
+			toc, err := aliasFileTOC(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			headerSize := int(binary.LittleEndian.Uint32(data[16:]))
+			pathArray := data[toc[KBookmarkPath]:]
+			nItems := int(binary.LittleEndian.Uint32(pathArray)) / 4
+			lastItem := int(binary.LittleEndian.Uint32(pathArray[8+(nItems-1)*4:]))
+			if toc[KBookmarkFullFileName] != headerSize+lastItem {
+				t.Errorf("full file name at %d, expected the last path item offset %d", toc[KBookmarkFullFileName], headerSize+lastItem)
+			}
+
+			got, err := AliasFromReader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("AliasFromReader() error = %v", err)
+			}
+			if got.Filename != got.Path[len(got.Path)-1] {
+				t.Errorf("Filename = %q, want %q", got.Filename, got.Path[len(got.Path)-1])
+			}
+		})
+	}
+}
+
+func TestAliasFromReader_minimal(t *testing.T) {
+	// minimal bookmark to /Users/mattetti/Downloads/file.wav, only the
+	// creation options, path and volume path are stored.
+	f, err := os.Open("fixtures/minimalAlias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := AliasFromReader(f)
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if !got.IsMinimal {
+		t.Error("expected the bookmark to be flagged as minimal")
+	}
+	want := &BookmarkData{
+		Path:            []string{"Users", "mattetti", "Downloads", "file.wav"},
+		VolumePath:      "/",
+		CreationOptions: 512,
+		IsMinimal:       true,
+	}
+	wantKeys := []uint32{KBookmarkPath, KBookmarkVolumePath, KBookmarkCreationOptions}
+	if keys := got.TOCKeys(); !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("TOCKeys() = %#x, want %#x", keys, wantKeys)
+	}
+	got.rawEntries, got.tocKeys = nil, nil
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AliasFromReader() = %#v, want %#v", got, want)
+	}
+	if got.TargetPath() != "/Users/mattetti/Downloads/file.wav" {
+		t.Errorf("TargetPath() = %s, want /Users/mattetti/Downloads/file.wav", got.TargetPath())
+	}
+}
+
+func TestAliasFromReader_multipleTOCs(t *testing.T) {
+	data, err := ioutil.ReadFile("fixtures/minimalAlias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	headerSize := binary.LittleEndian.Uint32(data[16:])
+	firstTOC := binary.LittleEndian.Uint32(data[headerSize:])
+
+	// second TOC with a new entry and a volume path which must not replace
+	// the one of the first TOC
+	userName := uint32(len(data)) - headerSize
+	data = append(data, encodedStringItem("cocoa")...)
+	volumePath := uint32(len(data)) - headerSize
+	data = append(data, encodedStringItem("/Volumes/Other")...)
+	secondTOC := uint32(len(data)) - headerSize
+	toc := make([]byte, tocHeaderSize+2*12)
+	binary.LittleEndian.PutUint32(toc, uint32(len(toc)-8))
+	copy(toc[4:], []byte{0xFE, 0xFF, 0xFF, 0xFF})
+	binary.LittleEndian.PutUint32(toc[8:], 2)
+	binary.LittleEndian.PutUint32(toc[16:], 2)
+	for i, entry := range [][2]uint32{{KBookmarkUserName, userName}, {KBookmarkVolumePath, volumePath}} {
+		binary.LittleEndian.PutUint32(toc[tocHeaderSize+i*12:], entry[0])
+		binary.LittleEndian.PutUint32(toc[tocHeaderSize+i*12+4:], entry[1])
+	}
+	data = append(data, toc...)
+	binary.LittleEndian.PutUint32(data[24:], uint32(len(data))-headerSize)
+	// chain the first TOC to the new one
+	binary.LittleEndian.PutUint32(data[headerSize+firstTOC+12:], secondTOC)
+
+	got, err := AliasFromReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if got.UserName != "cocoa" {
+		t.Errorf("UserName = %q, want the entry of the second TOC", got.UserName)
+	}
+	if got.VolumePath != "/" {
+		t.Errorf("VolumePath = %q, want the entry of the first TOC", got.VolumePath)
+	}
+	wantKeys := []uint32{KBookmarkPath, KBookmarkVolumePath, KBookmarkUserName, KBookmarkCreationOptions}
+	if keys := got.TOCKeys(); !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("TOCKeys() = %#x, want %#x", keys, wantKeys)
+	}
+
+	// TOCs pointing back to each other
+	binary.LittleEndian.PutUint32(data[headerSize+secondTOC+12:], firstTOC)
+	if _, err := AliasFromReader(bytes.NewReader(data)); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("AliasFromReader() error = %v, expected a TOC cycle error", err)
+	}
+}
+
+func TestAliasFromReader_relativeVolumeURL(t *testing.T) {
+	// alias to /build/assets/logo.png relative to /build/bin
+	f, err := os.Open("fixtures/relativeAlias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := AliasFromReader(f)
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if !got.VolumeURLIsRelative {
+		t.Error("expected the volume URL to be flagged as relative")
+	}
+	if got.VolumeURL != "../assets/logo.png" {
+		t.Errorf("VolumeURL = %q, want %q", got.VolumeURL, "../assets/logo.png")
+	}
+	if got.TargetPath() != "/build/assets/logo.png" {
+		t.Errorf("TargetPath() = %s, want /build/assets/logo.png", got.TargetPath())
+	}
+	// build comes from the base URL, assets/logo.png from the relative URL
+	if !reflect.DeepEqual(got.URLLengths, []uint32{1, 2}) {
+		t.Errorf("URLLengths = %v, want [1 2]", got.URLLengths)
+	}
+	if path, err := got.RelativeTargetPath("/Volumes/Backup/build/bin"); err != nil || path != "/Volumes/Backup/build/assets/logo.png" {
+		t.Errorf("RelativeTargetPath() = %s, %v, want /Volumes/Backup/build/assets/logo.png", path, err)
+	}
+}
+
+func TestAliasFromReader_securityExtension(t *testing.T) {
+	// security scoped alias to /Users/mattetti/Documents/notes.txt
+	f, err := os.Open("fixtures/securityScopedAlias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := AliasFromReader(f)
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if _, ok := got.Unknown[KBookmarkSecurityExtension]; ok {
+		t.Error("the security extension shouldn't be reported as unknown")
+	}
+	if !bytes.Contains(got.SecurityExtension, []byte(";com.apple.app-sandbox.read-write;")) {
+		t.Errorf("SecurityExtension = %q, expected a read-write sandbox extension", got.SecurityExtension)
+	}
+	if got.TargetPath() != "/Users/mattetti/Documents/notes.txt" {
+		t.Errorf("TargetPath() = %s, want /Users/mattetti/Documents/notes.txt", got.TargetPath())
+	}
+
+	f, err = os.Open("fixtures/alias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err = AliasFromReader(f)
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if got.SecurityExtension != nil {
+		t.Errorf("SecurityExtension = %q, want nil for a regular alias", got.SecurityExtension)
+	}
+}
+
+func TestAliasFromReader_emptyPath(t *testing.T) {
+	data := &BookmarkData{
+		Path:                []string{},
+		CNIDPath:            []uint64{},
+		ContainingFolderIDX: 7,
+		VolumePath:          "/",
+		VolumeIsRoot:        true,
+		VolumeURL:           "file:///",
+		VolumeName:          "Macintosh HD",
+		UserName:            "mattetti",
+	}
+	w := &bytes.Buffer{}
+	if err := data.Write(w); err != nil {
+		t.Fatal(err)
+	}
+	got, err := AliasFromReader(bytes.NewReader(w.Bytes()))
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if len(got.Path) != 0 {
+		t.Errorf("AliasFromReader().Path = %v, want an empty path", got.Path)
+	}
+	if got.ContainingFolderIDX != 0 {
+		t.Errorf("AliasFromReader().ContainingFolderIDX = %v, want 0", got.ContainingFolderIDX)
+	}
+	if got.TargetPath() != "/" {
+		t.Errorf("AliasFromReader().TargetPath() = %v, want /", got.TargetPath())
+	}
+}
+
+func TestAliasFromReader_withoutCNIDPath(t *testing.T) {
+	data := &BookmarkData{
+		Path:                []string{"Users", "mattetti", "Downloads", "file.wav"},
+		ContainingFolderIDX: 2,
+		VolumePath:          "/",
+		VolumeIsRoot:        true,
+		VolumeURL:           "file:///",
+		VolumeName:          "Macintosh HD",
+		UserName:            "mattetti",
+	}
+	w := &bytes.Buffer{}
+	if err := data.Write(w); err != nil {
+		t.Fatal(err)
+	}
+	got, err := AliasFromReader(bytes.NewReader(w.Bytes()))
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if len(got.CNIDPath) != 0 {
+		t.Errorf("AliasFromReader().CNIDPath = %v, want an empty CNID path", got.CNIDPath)
+	}
+	if !reflect.DeepEqual(got.Path, data.Path) {
+		t.Errorf("AliasFromReader().Path = %v, want %v", got.Path, data.Path)
+	}
+	if want := "/Users/mattetti/Downloads/file.wav"; got.TargetPath() != want {
+		t.Errorf("AliasFromReader().TargetPath() = %v, want %v", got.TargetPath(), want)
+	}
+}
+
+func TestAliasFromReaderWithOpts_truncatedVolumeURL(t *testing.T) {
+	data := &BookmarkData{
+		Path:         []string{"Users", "mattetti", "file.wav"},
+		VolumePath:   "/",
+		VolumeIsRoot: true,
+		VolumeURL:    "file:///",
+		VolumeName:   "Macintosh HD",
+		UserName:     "mattetti",
+	}
+	w := &bytes.Buffer{}
+	if err := data.Write(w); err != nil {
+		t.Fatal(err)
+	}
+	raw := w.Bytes()
+	// the volume url record is made of its length, its type and the url itself,
+	// claim a length going past the end of the data.
+	idx := bytes.Index(raw, []byte(data.VolumeURL))
+	if idx < 8 {
+		t.Fatal("volume url not found in the encoded bookmark")
+	}
+	binary.LittleEndian.PutUint32(raw[idx-8:], uint32(len(raw)))
+
+	if _, err := AliasFromReader(bytes.NewReader(raw)); err == nil {
+		t.Fatal("expected the truncated volume url to fail the decoding")
+	}
+
+	got, err := AliasFromReaderWithOpts(bytes.NewReader(raw), ParseOptions{ContinueOnError: true})
+	if err != nil {
+		t.Fatalf("AliasFromReaderWithOpts() error = %v", err)
+	}
+	if len(got.DecodeErrors) != 1 {
+		t.Fatalf("expected 1 decode error, got %v", got.DecodeErrors)
+	}
+	if got.VolumeURL != "" {
+		t.Errorf("AliasFromReaderWithOpts().VolumeURL = %v, expected it to be empty", got.VolumeURL)
+	}
+	if got.VolumeName != data.VolumeName {
+		t.Errorf("AliasFromReaderWithOpts().VolumeName = %v, want %v", got.VolumeName, data.VolumeName)
+	}
+	if !reflect.DeepEqual(got.Path, data.Path) {
+		t.Errorf("AliasFromReaderWithOpts().Path = %v, want %v", got.Path, data.Path)
+	}
+}
+
+func TestFixtures_roundTrip(t *testing.T) {
+	fixtures := []string{"fixtures/alias", "fixtures/exFATAlias"}
+	for _, fixture := range fixtures {
+		t.Run(fixture, func(t *testing.T) {
+			f, err := os.Open(fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			want, err := AliasFromReader(f)
+			if err != nil {
+				t.Fatalf("AliasFromReader() error = %v", err)
+			}
+
+			w := &bytes.Buffer{}
+			if err := want.Write(w); err != nil {
+				t.Fatalf("BookmarkData.Write() error = %v", err)
+			}
+			got, err := AliasFromReader(w)
+			if err != nil {
+				t.Fatalf("AliasFromReader() error decoding the re-encoded bookmark = %v", err)
+			}
+
+			if !reflect.DeepEqual(got.Path, want.Path) {
+				t.Errorf("Path = %v, want %v", got.Path, want.Path)
+			}
+			if !reflect.DeepEqual(got.CNIDPath, want.CNIDPath) {
+				t.Errorf("CNIDPath = %v, want %v", got.CNIDPath, want.CNIDPath)
+			}
+			if !got.FileCreationDate.Equal(want.FileCreationDate) {
+				t.Errorf("FileCreationDate = %v, want %v", got.FileCreationDate, want.FileCreationDate)
+			}
+			if !bytes.Equal(got.FileProperties, want.FileProperties) {
+				t.Errorf("FileProperties = %#v, want %#v", got.FileProperties, want.FileProperties)
+			}
+			if got.VolumePath != want.VolumePath {
+				t.Errorf("VolumePath = %v, want %v", got.VolumePath, want.VolumePath)
+			}
+			if got.VolumeURL != want.VolumeURL {
+				t.Errorf("VolumeURL = %v, want %v", got.VolumeURL, want.VolumeURL)
+			}
+			if got.VolumeName != want.VolumeName {
+				t.Errorf("VolumeName = %v, want %v", got.VolumeName, want.VolumeName)
+			}
+			if got.VolumeUUID != want.VolumeUUID {
+				t.Errorf("VolumeUUID = %v, want %v", got.VolumeUUID, want.VolumeUUID)
+			}
+			if got.VolumeSize != want.VolumeSize {
+				t.Errorf("VolumeSize = %v, want %v", got.VolumeSize, want.VolumeSize)
+			}
+			if !got.VolumeCreationDate.Equal(want.VolumeCreationDate) {
+				t.Errorf("VolumeCreationDate = %v, want %v", got.VolumeCreationDate, want.VolumeCreationDate)
+			}
+			if !bytes.Equal(got.VolumeProperties, want.VolumeProperties) {
+				t.Errorf("VolumeProperties = %#v, want %#v", got.VolumeProperties, want.VolumeProperties)
+			}
+			if got.TargetPath() != want.TargetPath() {
+				t.Errorf("TargetPath() = %v, want %v", got.TargetPath(), want.TargetPath())
+			}
+			if !bytes.Equal(got.TypeData, want.TypeData) {
+				t.Errorf("TypeData = %x, want %x", got.TypeData, want.TypeData)
+			}
+		})
+	}
+}
+
+func TestAliasFromReader_largeContainingFolderIDX(t *testing.T) {
+	data := &BookmarkData{
+		Path:                []string{"Users", "mattetti", "file.wav"},
+		ContainingFolderIDX: 0xfffffff0,
+		VolumePath:          "/",
+		VolumeIsRoot:        true,
+		VolumeURL:           "file:///",
+		UserName:            "mattetti",
+	}
+	w := &bytes.Buffer{}
+	if err := data.Write(w); err != nil {
+		t.Fatal(err)
+	}
+	got, err := AliasFromReader(w)
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if got.ContainingFolderIDX != data.ContainingFolderIDX {
+		t.Errorf("AliasFromReader().ContainingFolderIDX = %#x, want %#x", got.ContainingFolderIDX, data.ContainingFolderIDX)
+	}
+}
+
+func TestFixtures_unknownEntries(t *testing.T) {
+	// 0x1054, 0x1055 and 0x1056 aren't understood by the decoder.
+	unknownKeys := []uint32{KBookmarkUnknown, KBookmarkUnknown1, KBookmarkUnknown2}
+	fixtures := []string{"fixtures/alias", "fixtures/exFATAlias"}
+	for _, fixture := range fixtures {
+		t.Run(fixture, func(t *testing.T) {
+			f, err := os.Open(fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			want, err := AliasFromReader(f)
+			if err != nil {
+				t.Fatalf("AliasFromReader() error = %v", err)
+			}
+			for _, key := range unknownKeys {
+				if _, ok := want.Unknown[key]; !ok {
+					t.Fatalf("expected %#x to be preserved as an unknown entry", key)
+				}
+			}
+
+			w := &bytes.Buffer{}
+			if err := want.Write(w); err != nil {
+				t.Fatalf("BookmarkData.Write() error = %v", err)
+			}
+			got, err := AliasFromReader(w)
+			if err != nil {
+				t.Fatalf("AliasFromReader() error decoding the re-encoded bookmark = %v", err)
+			}
+			for _, key := range unknownKeys {
+				if !bytes.Equal(got.Unknown[key], want.Unknown[key]) {
+					t.Errorf("Unknown[%#x] = %#v, want %#v", key, got.Unknown[key], want.Unknown[key])
+				}
+			}
+		})
+	}
+}
+
+func TestBookmarkData_RawEntry(t *testing.T) {
+	f, err := os.Open("fixtures/alias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	b, err := AliasFromReader(f)
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+
+	raw, ok := b.RawEntry(KBookmarkVolumeURL)
+	if !ok {
+		t.Fatal("missing raw volume url entry")
+	}
+	want := append([]byte{8, 0, 0, 0, 0x01, 0x09, 0, 0}, "file:///"...)
+	if !bytes.Equal(raw, want) {
+		t.Errorf("RawEntry(KBookmarkVolumeURL) = %#v, want %#v", raw, want)
+	}
+	if raw, ok = b.RawEntry(KBookmarkUnknown); !ok || len(raw) < 8 {
+		t.Errorf("RawEntry(KBookmarkUnknown) = %#v, %t", raw, ok)
+	}
+	if _, ok = b.RawEntry(KBookmarkSecurityExtension); ok {
+		t.Error("didn't expect a security extension entry")
+	}
+}
+
+func TestAliasFromReader_externalVolumeUnknownKey(t *testing.T) {
+	// no Finder bookmark containing 0x2070 was available, the fixture is the
+	// exFAT one written back with an entry of unknown type under that key.
+	f, err := os.Open("fixtures/exFATUnknownKeyAlias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	b, err := AliasFromReader(f)
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	record := []byte{4, 0, 0, 0, 0x01, 0x02, 0, 0, 0xde, 0xad, 0xbe, 0xef}
+	if !bytes.Equal(b.Unknown[KBookmarkVolumeUnknown], record) {
+		t.Fatalf("Unknown[KBookmarkVolumeUnknown] = %#v, want %#v", b.Unknown[KBookmarkVolumeUnknown], record)
+	}
+
+	w := &bytes.Buffer{}
+	if err := b.Write(w); err != nil {
+		t.Fatal(err)
+	}
+	got, err := AliasFromReader(w)
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if !bytes.Equal(got.Unknown[KBookmarkVolumeUnknown], record) {
+		t.Errorf("Unknown[KBookmarkVolumeUnknown] = %#v after a round trip, want %#v", got.Unknown[KBookmarkVolumeUnknown], record)
+	}
+	if got.VolumePath != b.VolumePath || got.VolumeName != b.VolumeName {
+		t.Errorf("volume %s (%s) after a round trip, want %s (%s)", got.VolumePath, got.VolumeName, b.VolumePath, b.VolumeName)
+	}
+}
+
+func TestAliasFromReader_unknownVolumeCreationDate(t *testing.T) {
+	// the exFAT volume creation date couldn't be read when the alias was made
+	f, err := os.Open("fixtures/exFATAlias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := AliasFromReader(f)
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if !got.VolumeCreationDate.IsZero() {
+		t.Errorf("VolumeCreationDate = %v, expected a zero time", got.VolumeCreationDate)
+	}
+
+	data := &BookmarkData{
+		Path:         []string{"Users", "mattetti", "file.wav"},
+		VolumePath:   "/",
+		VolumeIsRoot: true,
+		VolumeURL:    "file:///",
+	}
+	w := &bytes.Buffer{}
+	if err := data.Write(w); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = AliasFromReader(w); err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if !got.VolumeCreationDate.IsZero() {
+		t.Errorf("VolumeCreationDate = %v, expected a zero time after a round trip", got.VolumeCreationDate)
+	}
+}
+
+func TestAliasFromReader_blankVolumeUUID(t *testing.T) {
+	data := &BookmarkData{
+		Path:       []string{"Volumes", "MattSplice", "file.wav"},
+		VolumePath: "/Volumes/MattSplice",
+		VolumeURL:  "file:///Volumes/MattSplice/",
+		VolumeName: "MattSplice",
+		VolumeUUID: blankUUID,
+	}
+	w := &bytes.Buffer{}
+	if err := data.Write(w); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := BookmarkKeys(w.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		if key == KBookmarkVolumeUUID {
+			t.Fatal("the blank volume UUID shouldn't be encoded")
+		}
+	}
+	got, err := AliasFromReader(bytes.NewReader(w.Bytes()))
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if got.VolumeUUID != "" {
+		t.Errorf("VolumeUUID = %q, expected an empty UUID when the key is absent", got.VolumeUUID)
+	}
+
+	// aliases created by older versions stored the zero UUID
+	data.VolumeUUID = ""
+	data.Unknown = map[uint32][]byte{KBookmarkVolumeUUID: encodedStringItem(blankUUID)}
+	w.Reset()
+	if err := data.Write(w); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = AliasFromReader(w); err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if got.VolumeUUID != "" {
+		t.Errorf("VolumeUUID = %q, expected the zero UUID to be decoded as empty", got.VolumeUUID)
+	}
+}
+
+func TestAliasFromReader_uuidVolumeUUID(t *testing.T) {
+	// some producers store the volume UUID as a UUID record
+	uuid := [16]byte{0x0a, 0x81, 0xf3, 0xb1, 0x51, 0xd9, 0x33, 0x35, 0xb3, 0xe3, 0x16, 0x9c, 0x36, 0x40, 0x36, 0x0d}
+	data := &BookmarkData{
+		Path:       []string{"Volumes", "MattSplice", "file.wav"},
+		VolumePath: "/Volumes/MattSplice",
+		VolumeURL:  "file:///Volumes/MattSplice/",
+		VolumeName: "MattSplice",
+		Unknown:    map[uint32][]byte{KBookmarkVolumeUUID: encodedUUID(uuid)},
+	}
+	w := &bytes.Buffer{}
+	if err := data.Write(w); err != nil {
+		t.Fatal(err)
+	}
+	got, err := AliasFromReader(w)
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if want := "0A81F3B1-51D9-3335-B3E3-169C3640360D"; got.VolumeUUID != want {
+		t.Errorf("VolumeUUID = %q, want %q", got.VolumeUUID, want)
+	}
+}
+
+func TestAliasFromReaderWithOpts_invalidUTF8(t *testing.T) {
+	data := &BookmarkData{
+		Path:         []string{"Users", "matt\xffetti", "file.wav"},
+		VolumePath:   "/",
+		VolumeIsRoot: true,
+		VolumeURL:    "file:///",
+	}
+	w := &bytes.Buffer{}
+	if err := data.Write(w); err != nil {
+		t.Fatal(err)
+	}
+	raw := w.Bytes()
+
+	// no validation by default
+	got, err := AliasFromReader(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Path, data.Path) {
+		t.Errorf("AliasFromReader().Path = %q, want %q", got.Path, data.Path)
+	}
+
+	_, err = AliasFromReaderWithOpts(bytes.NewReader(raw), ParseOptions{ValidateUTF8: true})
+	if !errors.Is(err, ErrInvalidUTF8) {
+		t.Fatalf("AliasFromReaderWithOpts() error = %v, want ErrInvalidUTF8", err)
+	}
+	if !strings.Contains(err.Error(), "path component 1") {
+		t.Errorf("expected the error to point to the offending component, got %v", err)
+	}
+
+	f, err := os.Open("fixtures/alias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := AliasFromReaderWithOpts(f, ParseOptions{ValidateUTF8: true}); err != nil {
+		t.Errorf("AliasFromReaderWithOpts() error = %v on a valid alias", err)
+	}
+}
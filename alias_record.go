@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"path/filepath"
 	"strings"
 	"time"
@@ -37,6 +39,13 @@ const (
 	AliasKindFolder = 1
 )
 
+const (
+	// size of the fixed part of an alias record, the tags follow.
+	aliasRecordHeaderSize = 150
+	// end of the tag list
+	aliasTagEnd uint16 = 0xffff
+)
+
 // AliasRecord format documented by Alastair Houghton
 // http://mac-alias.readthedocs.io/en/latest/alias_fmt.html
 
@@ -110,7 +119,10 @@ func (e *aliasRecordEncoder) encode() ([]byte, error) {
 	e.write(e.pascalString(e.carbonize(e.record.VolumeName), 28))
 	e.add(uint32(e.dateInSecs(e.record.VolumeDate)))
 
-	e.write([]byte(e.record.FileSystem))
+	// the filesystem is a fixed size 2 character code
+	fs := make([]byte, 2)
+	copy(fs, e.record.FileSystem)
+	e.write(fs)
 	e.add(e.record.DiskType)
 
 	e.add(e.record.FolderCNID)
@@ -156,6 +168,10 @@ func (e *aliasRecordEncoder) add(src interface{}) {
 }
 
 func (e *aliasRecordEncoder) pascalString(str string, size int) []byte {
+	// the string can't overflow its fixed size field (including its length byte)
+	if len(str) > size-1 {
+		str = str[:size-1]
+	}
 	data := append([]byte{byte(uint8(len(str)))}, []byte(str)...)
 	if extra := size - len(data); extra > 0 {
 		data = append(data, make([]byte, extra)...)
@@ -183,10 +199,15 @@ func (e *aliasRecordEncoder) folderNameTag() {
 	}
 }
 
+// carbonPathTag writes the ':' separated path starting with the volume name.
+// Each item is carbonized on its own so the separators are left alone.
 func (e *aliasRecordEncoder) carbonPathTag() {
 	e.add(aliasTagCarbonPath)
-	fullPath := filepath.Join(e.record.PathItems...)
-	carbonPath := strings.Join([]string{e.carbonize(e.record.VolumeName), e.carbonize(fullPath)}, ":")
+	items := []string{e.carbonize(e.record.VolumeName)}
+	for _, item := range e.record.PathItems {
+		items = append(items, e.carbonize(item))
+	}
+	carbonPath := strings.Join(items, ":")
 	length := uint16(len(carbonPath))
 	e.add(uint16(length))
 	e.write([]byte(carbonPath))
@@ -235,7 +256,7 @@ func (e *aliasRecordEncoder) dateTags() {
 	e.add(uint64(e.dateInSecs(e.record.VolumeDate)) * 65536)
 	e.add(aliasTagHighResCreationDate)
 	e.add(uint16(8))
-	e.add(uint64(e.dateInSecs(e.record.TargetCreation) * 65536))
+	e.add(uint64(e.dateInSecs(e.record.TargetCreation)) * 65536)
 }
 
 func (e *aliasRecordEncoder) cnidPathTag() {
@@ -257,6 +278,244 @@ func (e *aliasRecordEncoder) setError(err error) error {
 	}
 	if e.err != nil {
 		e.err = fmt.Errorf("%v - %v", e.err, err)
+	} else {
+		e.err = err
 	}
 	return e.err
 }
+
+// AliasRecordFromReader decodes the alias record read from the passed reader.
+func AliasRecordFromReader(r io.Reader) (*AliasRecord, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source - %s", err)
+	}
+	d := &aliasRecordDecoder{r: bytes.NewReader(data), record: &AliasRecord{}}
+	return d.decode()
+}
+
+// Decode parses the alias record data, as returned by Encode, into a.
+// a isn't modified if the data can't be decoded.
+func (a *AliasRecord) Decode(data []byte) error {
+	d := &aliasRecordDecoder{r: bytes.NewReader(data), record: &AliasRecord{}}
+	decoded, err := d.decode()
+	if err != nil {
+		return err
+	}
+	*a = *decoded
+	return nil
+}
+
+type aliasRecordDecoder struct {
+	r      *bytes.Reader
+	record *AliasRecord
+	err    error
+}
+
+func (d *aliasRecordDecoder) decode() (*AliasRecord, error) {
+	if d.r.Len() < aliasRecordHeaderSize {
+		return nil, fmt.Errorf("invalid alias record - %d bytes is too short", d.r.Len())
+	}
+	a := d.record
+	d.read(&a.AppCode)
+	var size uint16
+	d.read(&size)
+	if int64(size) > d.r.Size() {
+		return nil, fmt.Errorf("invalid alias record - record size %d exceeds the %d bytes of data", size, d.r.Size())
+	}
+	d.read(&a.Version)
+	if d.err == nil && a.Version != 2 {
+		return nil, fmt.Errorf("unsupported alias record version %d, only version 2 is supported", a.Version)
+	}
+	d.read(&a.Kind)
+
+	var err error
+	if a.VolumeName, err = d.pascalString(28); err != nil {
+		return nil, fmt.Errorf("failed to decode the volume name - %s", err)
+	}
+	a.VolumeDate = d.date()
+
+	fs := make([]byte, 2)
+	d.read(&fs)
+	a.FileSystem = string(fs)
+	d.read(&a.DiskType)
+	d.read(&a.FolderCNID)
+
+	if a.TargetName, err = d.pascalString(64); err != nil {
+		return nil, fmt.Errorf("failed to decode the target name - %s", err)
+	}
+	d.read(&a.TargetCNID)
+	a.TargetCreation = d.date()
+	d.read(&a.TargetCreator)
+	d.read(&a.TargetType)
+	d.read(&a.DirsAliasToRoot)
+	d.read(&a.DirsRootToTarget)
+	d.read(&a.VolumeAttributes)
+	d.read(&a.VolumeID)
+	// reserved
+	d.r.Seek(10, io.SeekCurrent)
+	if d.err != nil {
+		return nil, fmt.Errorf("failed to decode the alias record header - %s", d.err)
+	}
+
+	if err = d.tags(); err != nil {
+		return a, err
+	}
+	return a, nil
+}
+
+// tags reads the variable length tagged records following the header.
+func (d *aliasRecordDecoder) tags() error {
+	a := d.record
+	var tag, length uint16
+	// the posix path is relative to the volume mount point
+	var posixPath, mountPoint, carbonPath string
+	for {
+		d.read(&tag)
+		if d.err != nil {
+			return fmt.Errorf("failed to read the next tag - %s", d.err)
+		}
+		if tag == aliasTagEnd {
+			if posixPath != "" {
+				a.PathItems = strings.Split(posixPath, "/")
+			} else if carbonPath != "" {
+				// older records only have the carbon path, starting with
+				// the volume name.
+				if items := d.carbonPathItems(carbonPath); len(items) > 1 {
+					a.PathItems = items[1:]
+				}
+			}
+			if len(a.PathItems) > 0 {
+				a.Path = strings.TrimSuffix(mountPoint, "/") + "/" + strings.Join(a.PathItems, "/")
+			}
+			return nil
+		}
+		d.read(&length)
+		if d.err != nil {
+			return fmt.Errorf("failed to read the length of tag %d - %s", tag, d.err)
+		}
+		if int(length) > d.r.Len() {
+			return fmt.Errorf("tag %d length %d exceeds the %d remaining bytes", tag, length, d.r.Len())
+		}
+		value := make([]byte, length)
+		d.read(&value)
+		// optional padding
+		if length&1 > 0 && d.r.Len() > 0 {
+			d.r.Seek(1, io.SeekCurrent)
+		}
+
+		switch tag {
+		case aliasTagCarbonFolderName:
+			// derived from the path, nothing to store
+		case aliasTagCarbonPath:
+			carbonPath = string(value)
+		case aliasTagCnidPath:
+			a.CNIDPath = make([]uint32, len(value)/4)
+			for i := range a.CNIDPath {
+				a.CNIDPath[i] = binary.BigEndian.Uint32(value[i*4:])
+			}
+		case aliasTagUnicodeFilename:
+			// the pascal target name is truncated to 63 characters
+			name, err := d.unicodeString(value)
+			if err != nil {
+				return fmt.Errorf("failed to decode the unicode filename - %s", err)
+			}
+			a.TargetName = name
+		case aliasTagUnicodeVolumeName:
+			// the pascal volume name is truncated to 27 characters
+			name, err := d.unicodeString(value)
+			if err != nil {
+				return fmt.Errorf("failed to decode the unicode volume name - %s", err)
+			}
+			a.VolumeName = name
+		case aliasTagHighResVolumeCreationDate:
+			if len(value) != 8 {
+				return fmt.Errorf("invalid high resolution volume date length %d", len(value))
+			}
+			a.VolumeDate = d.highResDate(value)
+		case aliasTagHighResCreationDate:
+			if len(value) != 8 {
+				return fmt.Errorf("invalid high resolution creation date length %d", len(value))
+			}
+			a.TargetCreation = d.highResDate(value)
+		case aliasTagPosixPath:
+			posixPath = string(value)
+		case aliasTagPosixPathToMountpoint:
+			mountPoint = string(value)
+		}
+	}
+}
+
+func (d *aliasRecordDecoder) pascalString(size int) (string, error) {
+	data := make([]byte, size)
+	d.read(&data)
+	if d.err != nil {
+		return "", d.err
+	}
+	length := int(data[0])
+	if length > size-1 {
+		return "", fmt.Errorf("pascal string length %d exceeds its %d bytes", length, size-1)
+	}
+	return d.uncarbonize(string(data[1 : 1+length])), nil
+}
+
+// unicodeString decodes the value of the unicode name tags, a character count
+// followed by the UTF-16 big endian characters.
+func (d *aliasRecordDecoder) unicodeString(value []byte) (string, error) {
+	if len(value) < 2 {
+		return "", fmt.Errorf("%d bytes is too short", len(value))
+	}
+	count := int(binary.BigEndian.Uint16(value))
+	if 2+count*2 > len(value) {
+		return "", fmt.Errorf("%d characters don't fit in %d bytes", count, len(value)-2)
+	}
+	units := make([]uint16, count)
+	for i := range units {
+		units[i] = binary.BigEndian.Uint16(value[2+i*2:])
+	}
+	return d.uncarbonize(string(utf16.Decode(units))), nil
+}
+
+// highResDate decodes the high resolution dates, stored in 1/65536 seconds
+// since 1904-01-01 00:00:00 UTC.
+func (d *aliasRecordDecoder) highResDate(value []byte) time.Time {
+	ticks := binary.BigEndian.Uint64(value)
+	secs := ticks >> 16
+	frac := ticks & 0xffff
+	return aliasEpoch.Add(time.Duration(secs)*time.Second + time.Duration(frac)*time.Second/65536)
+}
+
+func (d *aliasRecordDecoder) date() time.Time {
+	var secs uint32
+	d.read(&secs)
+	return aliasEpoch.Add(time.Duration(secs) * time.Second)
+}
+
+// carbonPathItems splits the ':' separated carbon path. The '/' found in
+// names were carbonized as ":\x00" and are converted back.
+func (d *aliasRecordDecoder) carbonPathItems(carbonPath string) []string {
+	var items []string
+	for _, item := range strings.Split(carbonPath, ":") {
+		if strings.HasPrefix(item, "\x00") && len(items) > 0 {
+			items[len(items)-1] += "/" + item[1:]
+			continue
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// uncarbonize is the opposite of aliasRecordEncoder.carbonize
+func (d *aliasRecordDecoder) uncarbonize(str string) string {
+	return strings.Replace(str, string([]byte{':', 0x0}), "/", -1)
+}
+
+func (d *aliasRecordDecoder) read(dst interface{}) {
+	if d.err != nil {
+		return
+	}
+	d.err = binary.Read(d.r, binary.BigEndian, dst)
+	if d.err == io.EOF {
+		d.err = io.ErrUnexpectedEOF
+	}
+}
@@ -8,8 +8,16 @@ import (
 	"github.com/mattetti/cocoa/darwin"
 )
 
-// NewAliasRecord returns th alias record representation of a path
+// NewAliasRecord returns the alias record representation of a path.
+// If path is an alias, the record points to the file it resolves to, use
+// NewAliasRecordWithOpts with BookmarkOpts.Strict to reject such sources.
 func NewAliasRecord(path string) (*AliasRecord, error) {
+	return NewAliasRecordWithOpts(path, BookmarkOpts{})
+}
+
+// NewAliasRecordWithOpts is like NewAliasRecord but honors opts.Strict, the
+// other options only apply to bookmarks.
+func NewAliasRecordWithOpts(path string, opts BookmarkOpts) (*AliasRecord, error) {
 	a := &AliasRecord{Path: path}
 
 	srcPath, err := filepath.Abs(path)
@@ -36,13 +44,13 @@ func NewAliasRecord(path string) (*AliasRecord, error) {
 	}
 	volPath := string(volPathB)
 	// volume attributes
-	buf := make([]byte, 512)
-	volumeAttrs, err := darwin.GetAttrList(volPath,
-		darwin.AttrListMask{
-			CommonAttr: darwin.ATTR_CMN_CRTIME,
-			VolAttr:    darwin.ATTR_VOL_SIZE | darwin.ATTR_VOL_NAME | darwin.ATTR_VOL_UUID,
-		},
-		buf, 0|darwin.FSOPT_REPORT_FULLSIZE)
+	volumeMask := darwin.AttrListMask{
+		CommonAttr: darwin.ATTR_CMN_CRTIME,
+		VolAttr:    darwin.ATTR_VOL_SIZE | darwin.ATTR_VOL_NAME | darwin.ATTR_VOL_UUID,
+	}
+	// the buffer must fit the longest volume name
+	buf := make([]byte, darwin.AttrBufSize(volumeMask))
+	volumeAttrs, err := darwin.GetAttrList(volPath, volumeMask, buf, 0|darwin.FSOPT_REPORT_FULLSIZE)
 	if err != nil {
 		return a, fmt.Errorf("failed to retrieve volume attribute list - %s", err)
 	}
@@ -58,18 +66,24 @@ func NewAliasRecord(path string) (*AliasRecord, error) {
 			CommonAttr: darwin.ATTR_CMN_OBJTYPE |
 				darwin.ATTR_CMN_FNDRINFO |
 				darwin.ATTR_CMN_CRTIME |
-				darwin.ATTR_CMN_FILEID,
+				darwin.ATTR_CMN_FILEID |
+				darwin.ATTR_CMN_PARENTID,
 		},
 		buf, darwin.FSOPT_NOFOLLOW) // maybe we should follow so we don't have issues with symlinks and aliases?
 	if err != nil {
 		return a, fmt.Errorf("failed to retrieve file attribute list - %s", err)
 	}
 
-	// TODO: decode the source alias and adjust the source instead of failing.
-	// macOS UI lest you create an alias to an alias by reading the alias source
-	// and creating another version of the alias.
+	// like Finder, an alias to an alias points to the target of the source
 	if fileAttrs.FileInfo.FinderFlags&darwin.FFKIsAlias > 0 {
-		return a, fmt.Errorf("can't safely alias to an alias, choose another source")
+		if opts.Strict {
+			return a, fmt.Errorf("can't safely alias an alias, choose another source")
+		}
+		target, err := resolveAliasSource(srcPath)
+		if err != nil {
+			return a, err
+		}
+		return NewAliasRecordWithOpts(target, opts)
 	}
 
 	// target attributes
@@ -79,7 +93,7 @@ func NewAliasRecord(path string) (*AliasRecord, error) {
 		a.Kind = AliasKindFile
 	}
 	a.TargetName = filepath.Base(path)
-	a.TargetCNID = fileAttrs.FileID
+	a.TargetCNID = uint32(fileAttrs.FileID)
 	a.TargetCreation = fileAttrs.CreationTime.Time()
 	a.DirsAliasToRoot = -1
 	a.DirsRootToTarget = -1
@@ -92,8 +106,12 @@ func NewAliasRecord(path string) (*AliasRecord, error) {
 	if err != nil {
 		return a, fmt.Errorf("failed to retrieve file id for %s - %s", subPath, err)
 	}
-	a.CNIDPath = []uint32{subPathAttrs.FileID}
+	a.CNIDPath = []uint32{uint32(subPathAttrs.FileID)}
 	a.PathItems = []string{filepath.Base(filepath.Dir(subPath)), filepath.Base(subPath)}
+	if filepath.Dir(relPath) == "." {
+		// the target is at the root of the volume
+		a.PathItems = a.PathItems[1:]
+	}
 
 	// walk the path and extract the file id of each sub path
 	dir := filepath.Dir(relPath)
@@ -110,10 +128,9 @@ func NewAliasRecord(path string) (*AliasRecord, error) {
 		if err != nil {
 			return a, fmt.Errorf("failed to retrieve file id for %s - %s", subPath, err)
 		}
-		a.CNIDPath = append([]uint32{subPathAttrs.FileID}, a.CNIDPath...)
+		a.CNIDPath = append([]uint32{uint32(subPathAttrs.FileID)}, a.CNIDPath...)
 	}
-	folderIDX := len(a.CNIDPath) - 2
-	a.FolderCNID = a.CNIDPath[folderIDX]
+	a.FolderCNID = uint32(fileAttrs.ParentID)
 
 	return a, nil
 }
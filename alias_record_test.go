@@ -2,10 +2,13 @@ package cocoa
 
 import (
 	"bytes"
+	"encoding/binary"
 	"io/ioutil"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -273,8 +276,23 @@ func Test_aliasRecordEncoder_carbonPathTag(t *testing.T) {
 	tests := []struct {
 		name   string
 		fields fields
+		want   string
 	}{
-	// TODO: Add test cases.
+		{
+			name:   "path",
+			fields: fields{record: &AliasRecord{VolumeName: "Macintosh HD", PathItems: []string{"Users", "matt"}}, buf: &bytes.Buffer{}},
+			want:   "\x00\x02\x00\x17Macintosh HD:Users:matt\x00",
+		},
+		{
+			name:   "slash in a name",
+			fields: fields{record: &AliasRecord{VolumeName: "Macintosh HD", PathItems: []string{"a/b", "c"}}, buf: &bytes.Buffer{}},
+			want:   "\x00\x02\x00\x13Macintosh HD:a:\x00b:c\x00",
+		},
+		{
+			name:   "even length",
+			fields: fields{record: &AliasRecord{VolumeName: "HD", PathItems: []string{"a"}}, buf: &bytes.Buffer{}},
+			want:   "\x00\x02\x00\x04HD:a",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -284,6 +302,9 @@ func Test_aliasRecordEncoder_carbonPathTag(t *testing.T) {
 				err:    tt.fields.err,
 			}
 			e.carbonPathTag()
+			if got := e.buf.String(); got != tt.want {
+				t.Errorf("aliasRecordEncoder.carbonPathTag() wrote %q, want %q", got, tt.want)
+			}
 		})
 	}
 }
@@ -423,7 +444,8 @@ func Test_aliasRecordEncoder_carbonize(t *testing.T) {
 		args   args
 		want   string
 	}{
-	// TODO: Add test cases.
+		{name: "plain", args: args{"target.txt"}, want: "target.txt"},
+		{name: "slash", args: args{"a/b"}, want: "a:\x00b"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -469,3 +491,300 @@ func Test_aliasRecordEncoder_setError(t *testing.T) {
 		})
 	}
 }
+
+func TestAliasRecordFromReader_roundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	randName := func(max int) string {
+		const chars = "abcdefghijklmnopqrstuvwxyz ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789._-"
+		b := make([]byte, 1+rnd.Intn(max))
+		for i := range b {
+			b[i] = chars[rnd.Intn(len(chars))]
+		}
+		return string(b)
+	}
+
+	for i := 0; i < 100; i++ {
+		items := make([]string, 1+rnd.Intn(8))
+		cnids := make([]uint32, len(items))
+		for j := range items {
+			items[j] = randName(20)
+			cnids[j] = rnd.Uint32()
+		}
+		record := &AliasRecord{
+			Path:             "/" + strings.Join(items, "/"),
+			CNIDPath:         cnids,
+			PathItems:        items,
+			Version:          2,
+			Kind:             uint16(rnd.Intn(2)),
+			VolumeName:       randName(27),
+			VolumeDate:       aliasEpoch.Add(time.Duration(rnd.Uint32()) * time.Second),
+			FileSystem:       "H+",
+			DiskType:         uint16(rnd.Intn(6)),
+			FolderCNID:       rnd.Uint32(),
+			TargetName:       items[len(items)-1],
+			TargetCNID:       cnids[len(cnids)-1],
+			TargetCreation:   aliasEpoch.Add(time.Duration(rnd.Uint32()) * time.Second),
+			DirsAliasToRoot:  -1,
+			DirsRootToTarget: -1,
+			VolumeID:         uint16(rnd.Intn(0xffff)),
+		}
+		data, err := record.Encode()
+		if err != nil {
+			t.Fatalf("AliasRecord.Encode() error = %v", err)
+		}
+		got, err := AliasRecordFromReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("AliasRecordFromReader() error = %v", err)
+		}
+		if !got.VolumeDate.Equal(record.VolumeDate) || !got.TargetCreation.Equal(record.TargetCreation) {
+			t.Fatalf("dates didn't round trip, expected %v and %v, got %v and %v",
+				record.VolumeDate, record.TargetCreation, got.VolumeDate, got.TargetCreation)
+		}
+		got.VolumeDate, got.TargetCreation = record.VolumeDate, record.TargetCreation
+		if !reflect.DeepEqual(got, record) {
+			t.Fatalf("AliasRecord didn't round trip, expected %#v, got %#v", record, got)
+		}
+	}
+}
+
+func TestAliasRecord_Decode(t *testing.T) {
+	record := &AliasRecord{
+		Path:             "/Users/matt/a:b/target.txt",
+		CNIDPath:         []uint32{1, 2, 3, 4},
+		PathItems:        []string{"Users", "matt", "a:b", "target.txt"},
+		Version:          2,
+		VolumeName:       "Macintosh HD",
+		VolumeDate:       aliasEpoch.Add(3e9 * time.Second),
+		FileSystem:       "H+",
+		FolderCNID:       3,
+		TargetName:       "target.txt",
+		TargetCNID:       4,
+		TargetCreation:   aliasEpoch.Add(3.5e9 * time.Second),
+		DirsAliasToRoot:  -1,
+		DirsRootToTarget: -1,
+	}
+	data, err := record.Encode()
+	if err != nil {
+		t.Fatalf("AliasRecord.Encode() error = %v", err)
+	}
+	got := &AliasRecord{}
+	if err := got.Decode(data); err != nil {
+		t.Fatalf("AliasRecord.Decode() error = %v", err)
+	}
+	if !got.VolumeDate.Equal(record.VolumeDate) || !got.TargetCreation.Equal(record.TargetCreation) {
+		t.Fatalf("dates didn't round trip, expected %v and %v, got %v and %v",
+			record.VolumeDate, record.TargetCreation, got.VolumeDate, got.TargetCreation)
+	}
+	got.VolumeDate, got.TargetCreation = record.VolumeDate, record.TargetCreation
+	if !reflect.DeepEqual(got, record) {
+		t.Fatalf("AliasRecord didn't round trip, expected %#v, got %#v", record, got)
+	}
+
+	untouched := &AliasRecord{TargetName: "untouched"}
+	if err := untouched.Decode(data[:10]); err == nil {
+		t.Fatal("expected an error decoding truncated data")
+	}
+	if untouched.TargetName != "untouched" {
+		t.Fatalf("expected the record to be left alone on error, got %#v", untouched)
+	}
+}
+
+// withoutAliasTags returns the encoded alias record without the passed tags,
+// with its size updated.
+func withoutAliasTags(t *testing.T, data []byte, tags ...uint16) []byte {
+	out := append([]byte{}, data[:aliasRecordHeaderSize]...)
+	for pos := aliasRecordHeaderSize; pos < len(data); {
+		tag := binary.BigEndian.Uint16(data[pos:])
+		if tag == aliasTagEnd {
+			out = append(out, data[pos:]...)
+			binary.BigEndian.PutUint16(out[4:], uint16(len(out)))
+			return out
+		}
+		size := 4 + int(binary.BigEndian.Uint16(data[pos+2:]))
+		size += size & 1
+		keep := true
+		for _, skip := range tags {
+			keep = keep && tag != skip
+		}
+		if keep {
+			out = append(out, data[pos:pos+size]...)
+		}
+		pos += size
+	}
+	t.Fatal("missing end tag")
+	return nil
+}
+
+func TestAliasRecord_Decode_carbonPath(t *testing.T) {
+	record := &AliasRecord{
+		VolumeName: "Macintosh HD",
+		PathItems:  []string{"Users", "matt", "a/b", "target.txt"},
+		TargetName: "target.txt",
+	}
+	data, err := record.Encode()
+	if err != nil {
+		t.Fatalf("AliasRecord.Encode() error = %v", err)
+	}
+	// older records only have the carbon path
+	got := &AliasRecord{}
+	if err := got.Decode(withoutAliasTags(t, data, aliasTagPosixPath, aliasTagPosixPathToMountpoint)); err != nil {
+		t.Fatalf("AliasRecord.Decode() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.PathItems, record.PathItems) {
+		t.Errorf("PathItems = %q, want %q", got.PathItems, record.PathItems)
+	}
+	if want := "/Users/matt/a/b/target.txt"; got.Path != want {
+		t.Errorf("Path = %q, want %q", got.Path, want)
+	}
+}
+
+func Test_aliasRecordDecoder_carbonPathItems(t *testing.T) {
+	tests := []struct {
+		carbonPath string
+		want       []string
+	}{
+		{"Macintosh HD:Users:matt", []string{"Macintosh HD", "Users", "matt"}},
+		{"Macintosh HD:a:\x00b:c", []string{"Macintosh HD", "a/b", "c"}},
+		{"Macintosh HD", []string{"Macintosh HD"}},
+	}
+	d := &aliasRecordDecoder{}
+	for _, tt := range tests {
+		if got := d.carbonPathItems(tt.carbonPath); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("carbonPathItems(%q) = %q, want %q", tt.carbonPath, got, tt.want)
+		}
+	}
+}
+
+func TestAliasRecordFromReader_longNames(t *testing.T) {
+	record := &AliasRecord{
+		VolumeName: strings.Repeat("v", 40),
+		FileSystem: "H+",
+		TargetName: strings.Repeat("t", 80),
+		TargetCNID: 0x7dc0f5,
+	}
+	data, err := record.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := AliasRecordFromReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("AliasRecordFromReader() error = %v", err)
+	}
+	// the pascal strings are truncated but the unicode name tags aren't
+	if data[10] != 27 {
+		t.Errorf("expected the pascal volume name to be truncated to 27 characters, got %d", data[10])
+	}
+	if got.VolumeName != record.VolumeName {
+		t.Errorf("expected the full volume name from the unicode tag, got %q", got.VolumeName)
+	}
+	if got.TargetName != record.TargetName {
+		t.Errorf("expected the full target name from the unicode tag, got %q", got.TargetName)
+	}
+	if got.TargetCNID != record.TargetCNID {
+		t.Errorf("AliasRecordFromReader().TargetCNID = %#x, want %#x", got.TargetCNID, record.TargetCNID)
+	}
+}
+
+func TestAliasRecordFromReader_badTagLength(t *testing.T) {
+	raw, err := ioutil.ReadFile(filepath.Join("testExpectations", "cocoa.hex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// first tag after the header claims more data than available
+	data := append([]byte{}, raw[:aliasRecordHeaderSize+4]...)
+	data[aliasRecordHeaderSize+2] = 0xff
+	data[aliasRecordHeaderSize+3] = 0xff
+	if _, err := AliasRecordFromReader(bytes.NewReader(data)); err == nil {
+		t.Fatal("expected an error decoding a tag longer than the data")
+	}
+	// missing end tag
+	if _, err := AliasRecordFromReader(bytes.NewReader(raw[:len(raw)-4])); err == nil {
+		t.Fatal("expected an error decoding a record without end tag")
+	}
+}
+
+func TestAliasRecordFromReader(t *testing.T) {
+	f, err := os.Open(filepath.Join("testExpectations", "cocoa.hex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := AliasRecordFromReader(f)
+	if err != nil {
+		t.Fatalf("AliasRecordFromReader() error = %v", err)
+	}
+	want := &AliasRecord{
+		Path:             "/Users/mattetti/Code/golang/src/github.com/mattetti/cocoa/cocoa.go",
+		CNIDPath:         []uint32{0x669dc, 0x9b7c3, 0x105f25, 0x12fe65, 0x13053d, 0x1f86ca, 0x1fe5c4, 0x7dc0f5},
+		PathItems:        []string{"Users", "mattetti", "Code", "golang", "src", "github.com", "mattetti", "cocoa", "cocoa.go"},
+		Version:          2,
+		VolumeName:       "Macintosh HD",
+		FileSystem:       "H+",
+		FolderCNID:       0x1fe5c4,
+		TargetName:       "cocoa.go",
+		TargetCNID:       0x7dc0f5,
+		DirsAliasToRoot:  -1,
+		DirsRootToTarget: -1,
+	}
+	// the dates of the expectation overflow the 32 bit alias dates
+	got.VolumeDate, got.TargetCreation = time.Time{}, time.Time{}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AliasRecordFromReader() = %#v, want %#v", got, want)
+	}
+}
+
+func TestAliasRecordFromReader_tags(t *testing.T) {
+	raw, err := ioutil.ReadFile(filepath.Join("testExpectations", "cocoa.hex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// replace the mount point and end tags
+	data := append([]byte{}, raw[:len(raw)-10]...)
+	data = append(data, 0x00, 0x13, 0x00, 0x0e)
+	data = append(data, "/Volumes/Audio"...)
+	// high resolution creation date, half a second after the alias epoch
+	data = append(data, 0x00, 0x11, 0x00, 0x08, 0, 0, 0, 0, 0, 0, 0x80, 0x00)
+	data = append(data, 0xff, 0xff, 0x00, 0x00)
+
+	got, err := AliasRecordFromReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("AliasRecordFromReader() error = %v", err)
+	}
+	if want := "/Volumes/Audio/Users/mattetti/Code/golang/src/github.com/mattetti/cocoa/cocoa.go"; got.Path != want {
+		t.Errorf("AliasRecordFromReader().Path = %s, want %s", got.Path, want)
+	}
+	if want := aliasEpoch.Add(500 * time.Millisecond); !got.TargetCreation.Equal(want) {
+		t.Errorf("AliasRecordFromReader().TargetCreation = %v, want %v", got.TargetCreation, want)
+	}
+}
+
+func TestAliasRecordFromReader_version(t *testing.T) {
+	raw, err := ioutil.ReadFile(filepath.Join("testExpectations", "cocoa.hex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := append([]byte{}, raw...)
+	data[7] = 3
+	if _, err := AliasRecordFromReader(bytes.NewReader(data)); err == nil {
+		t.Fatal("expected an error decoding a version 3 alias record")
+	}
+}
+
+func FuzzAliasRecord(f *testing.F) {
+	raw, err := ioutil.ReadFile(filepath.Join("testExpectations", "cocoa.hex"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(raw)
+	f.Add(raw[:aliasRecordHeaderSize])
+	f.Add([]byte{})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		record, err := AliasRecordFromReader(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		if _, err := record.Encode(); err != nil {
+			t.Errorf("failed to encode a decoded alias record - %v", err)
+		}
+	})
+}
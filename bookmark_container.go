@@ -0,0 +1,57 @@
+package cocoa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// WriteBookmarks serializes a collection of bookmarks to the passed writer.
+// Each bookmark is written as its little endian uint32 length followed by its
+// alias data. Use ReadBookmarks to read them back.
+func WriteBookmarks(w io.Writer, bs []*BookmarkData) error {
+	buf := &bytes.Buffer{}
+	for i, b := range bs {
+		buf.Reset()
+		if err := b.Write(buf); err != nil {
+			return fmt.Errorf("failed to encode bookmark %d - %s", i, err)
+		}
+		if err := binary.Write(w, binary.LittleEndian, uint32(buf.Len())); err != nil {
+			return fmt.Errorf("failed to write the length of bookmark %d - %s", i, err)
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return fmt.Errorf("failed to write bookmark %d - %s", i, err)
+		}
+	}
+	return nil
+}
+
+// ReadBookmarks reads all the bookmarks written by WriteBookmarks.
+func ReadBookmarks(r io.Reader) ([]*BookmarkData, error) {
+	var bs []*BookmarkData
+	buf := &bytes.Buffer{}
+	for {
+		var size uint32
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+			if err == io.EOF {
+				return bs, nil
+			}
+			return bs, fmt.Errorf("failed to read the length of bookmark %d - %s", len(bs), err)
+		}
+		buf.Reset()
+		// copy instead of allocating the announced size upfront so a bad
+		// length can't trigger a huge allocation.
+		if _, err := io.CopyN(buf, r, int64(size)); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return bs, fmt.Errorf("failed to read bookmark %d - %s", len(bs), err)
+		}
+		b, err := AliasFromReader(buf)
+		if err != nil {
+			return bs, fmt.Errorf("failed to decode bookmark %d - %s", len(bs), err)
+		}
+		bs = append(bs, b)
+	}
+}
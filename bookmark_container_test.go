@@ -0,0 +1,76 @@
+package cocoa
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestWriteBookmarks_roundTrip(t *testing.T) {
+	var want []*BookmarkData
+	for _, fixture := range []string{"fixtures/alias", "fixtures/exFATAlias"} {
+		f, err := os.Open(fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := AliasFromReader(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, b)
+	}
+	want = append(want, &BookmarkData{
+		Path:         []string{"Users", "mattetti", "file.wav"},
+		VolumePath:   "/",
+		VolumeIsRoot: true,
+		VolumeURL:    "file:///",
+		VolumeName:   "Macintosh HD",
+		UserName:     "mattetti",
+	})
+
+	w := &bytes.Buffer{}
+	if err := WriteBookmarks(w, want); err != nil {
+		t.Fatalf("WriteBookmarks() error = %v", err)
+	}
+	got, err := ReadBookmarks(w)
+	if err != nil {
+		t.Fatalf("ReadBookmarks() error = %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ReadBookmarks() returned %d bookmarks, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].TargetPath() != want[i].TargetPath() {
+			t.Errorf("bookmark %d TargetPath() = %s, want %s", i, got[i].TargetPath(), want[i].TargetPath())
+		}
+		if !reflect.DeepEqual(got[i].Path, want[i].Path) {
+			t.Errorf("bookmark %d Path = %v, want %v", i, got[i].Path, want[i].Path)
+		}
+		if got[i].VolumeName != want[i].VolumeName {
+			t.Errorf("bookmark %d VolumeName = %s, want %s", i, got[i].VolumeName, want[i].VolumeName)
+		}
+	}
+}
+
+func TestReadBookmarks_truncated(t *testing.T) {
+	w := &bytes.Buffer{}
+	b := &BookmarkData{Path: []string{"tmp", "file"}, VolumePath: "/", VolumeIsRoot: true, VolumeURL: "file:///"}
+	if err := WriteBookmarks(w, []*BookmarkData{b, b}); err != nil {
+		t.Fatal(err)
+	}
+	data := w.Bytes()
+	got, err := ReadBookmarks(bytes.NewReader(data[:len(data)-10]))
+	if err == nil {
+		t.Fatal("expected an error reading truncated data")
+	}
+	if len(got) != 1 {
+		t.Errorf("expected the first bookmark to be returned, got %d", len(got))
+	}
+
+	got, err = ReadBookmarks(&bytes.Buffer{})
+	if err != nil || len(got) != 0 {
+		t.Errorf("ReadBookmarks() on empty data = %v, %v", got, err)
+	}
+}
@@ -2,13 +2,18 @@ package cocoa
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"image"
 	"io"
 	"path/filepath"
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/mattetti/cocoa/darwin"
 )
 
 // BookmarkData represents the data structure holding the bookmark information
@@ -24,22 +29,156 @@ type BookmarkData struct {
 	VolumePath          string
 	VolumeIsRoot        bool
 	VolumeURL           string // file://' + volPath
+	// VolumeURLIsRelative indicates that VolumeURL is relative to another
+	// location instead of being an absolute file URL.
+	VolumeURLIsRelative bool
+	URLLengths          []uint32 // path components from each URL, base URL first
+	VolumeMountPoint    string   // file URL of the mount point, set for disk images
 	VolumeName          string
 	VolumeSize          int64
 	VolumeCreationDate  time.Time
 	VolumeUUID          string // must be uppercase
 	VolumeProperties    []byte
-	CreationOptions     uint32 // 512
+	CreationOptions     uint32 // 1024
+	IsMinimal           bool   // decoded from CreationOptions, most volume and file info is missing
 	WasFileReference    bool   // true
 	UserName            string // unknown
-	CNID                uint32
+	CNID                uint64 // file ID of the target, written when set
 	UID                 uint32 // 99
 	Filename            string
+	// SecurityExtension is the opaque sandbox extension token of security
+	// scoped bookmarks created by sandboxed apps. It isn't encoded.
+	SecurityExtension []byte
+	// EmbeddedVolumeBookmark is the bookmark data of the disk image backing
+	// the target volume, see DiskImagePath.
+	EmbeddedVolumeBookmark []byte
+	// Defaulted lists the fields filled with fallback values when the bookmark
+	// was created because the real values couldn't be read, the bookmark might
+	// be of lower fidelity. It isn't encoded.
+	Defaulted []string
+	// DecodeErrors lists the entries that failed to decode when the bookmark
+	// was parsed with ParseOptions.ContinueOnError.
+	DecodeErrors []error
+	// Unknown holds the raw records (length, type and data) of the TOC
+	// entries the decoder doesn't understand, indexed by key.
+	// Write emits them back unless it already wrote the key itself. Arrays and
+	// dictionaries reference other records by offset and can't be relocated
+	// so they are not written back.
+	Unknown map[uint32][]byte
+	// Extras holds the decoded content of the unknown entries holding a
+	// dictionary, indexed by key. It isn't encoded. Dictionaries that can't
+	// be decoded are skipped and only kept in Unknown.
+	Extras map[uint32]interface{}
+
+	// rawEntries holds the raw records of all the decoded TOC entries.
+	rawEntries map[uint32][]byte
+	// tocKeys are the sorted keys found in the TOC of the decoded data.
+	tocKeys []uint32
+}
+
+// TOCKeys returns the sorted keys of the TOC of the decoded bookmark data,
+// see BookmarkKeyName to name them. Bookmarks which weren't decoded don't
+// have any.
+func (b *BookmarkData) TOCKeys() []uint32 {
+	return append([]uint32(nil), b.tocKeys...)
+}
+
+// RawEntry returns the raw record (length, type and data) of the TOC entry
+// with the passed key as found in the decoded bookmark data. Arrays contain
+// offsets to other records within the original data.
+func (b *BookmarkData) RawEntry(key uint32) ([]byte, bool) {
+	if raw, ok := b.rawEntries[key]; ok {
+		return raw, true
+	}
+	raw, ok := b.Unknown[key]
+	return raw, ok
+}
+
+// BookmarkOpts are the options used when creating a bookmark.
+type BookmarkOpts struct {
+	// SkipCNIDPath builds a path only bookmark without looking up the CNID
+	// of the target and its ancestors. This is useful for network or cross
+	// volume targets where the lookup is slow or fails. Finder resolves such
+	// bookmarks by path.
+	SkipCNIDPath bool
+	// IconLocation is the position of the alias icon in its Finder window.
+	IconLocation *image.Point
+	// TypeCode and CreatorCode are the Finder type and creator codes of the
+	// alias file, 'alis' and 'MACS' are used when not set.
+	TypeCode    darwin.FourCharCode
+	CreatorCode darwin.FourCharCode
+	// ClearQuarantine removes the com.apple.quarantine extended attribute
+	// from the alias file, which can be inherited by files created by
+	// downloaded tools.
+	ClearQuarantine bool
+	// Strict fails when the source is itself an alias instead of aliasing
+	// the file the source resolves to, like Finder does. It's also honored
+	// by NewAliasRecordWithOpts.
+	Strict bool
 }
 
 // TargetPath returns the full path to the current target url.
+// A bookmark without path components points to its volume.
 func (b *BookmarkData) TargetPath() string {
-	return fmt.Sprintf("%s%s", b.VolumePath, filepath.Join(b.Path...))
+	if len(b.Path) == 0 {
+		return b.VolumePath
+	}
+	// the path components usually start with the mount point of the volume
+	// which is skipped so it isn't repeated. Join takes care of missing or
+	// extra separators after the volume path.
+	return filepath.Join(append([]string{b.VolumePath}, b.volumeRelativePath()...)...)
+}
+
+// volumeRelativePath returns the path components of the target relative to
+// its volume.
+func (b *BookmarkData) volumeRelativePath() []string {
+	vol := pathComponents(b.VolumePath)
+	if len(vol) > len(b.Path) {
+		return b.Path
+	}
+	for i, c := range vol {
+		if b.Path[i] != c {
+			return b.Path
+		}
+	}
+	return b.Path[len(vol):]
+}
+
+// pathComponents splits the passed absolute slash separated path into its
+// components.
+func pathComponents(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// targetCNID returns the CNID of the bookmark target, found at the end of the
+// CNID path or in the file ID entry.
+func (b *BookmarkData) targetCNID() (uint64, bool) {
+	if len(b.CNIDPath) > 0 {
+		return b.CNIDPath[len(b.CNIDPath)-1], true
+	}
+	if b.CNID > 0 {
+		return b.CNID, true
+	}
+	return 0, false
+}
+
+// TargetFingerprint returns a hex encoded SHA-256 hash identifying the target
+// file independently of its path: the volume UUID and the target CNID.
+// Bookmarks to the same file via different paths have the same fingerprint.
+// An empty string is returned if the bookmark doesn't have a CNID.
+func (b *BookmarkData) TargetFingerprint() string {
+	cnid, ok := b.targetCNID()
+	if !ok {
+		return ""
+	}
+	h := sha256.New()
+	io.WriteString(h, strings.ToUpper(b.VolumeUUID))
+	binary.Write(h, binary.BigEndian, cnid)
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 // Write converts the bookmark data into binary data and writes it to the passed writer.
@@ -51,7 +190,11 @@ func (b *BookmarkData) Write(w io.Writer) error {
 	oMap := offsetMap{}
 
 	oMap[KBookmarkCreationOptions] = buf.Len()
-	buf.Write(encodedUint32(1024))
+	creationOptions := b.CreationOptions
+	if creationOptions == 0 {
+		creationOptions = uint32(BookmarkCreationSuitableForBookmarkFile)
+	}
+	buf.Write(encodedUint32(creationOptions))
 
 	slashPos := buf.Len()
 
@@ -76,9 +219,10 @@ func (b *BookmarkData) Write(w io.Writer) error {
 		if item == b.UserName {
 			usernameOffset = buf.Len()
 		}
-		// get the offset of the last item in the path
+		// the file name entry points to the record of the last path item, like
+		// all TOC entries its offset doesn't include the body size value.
 		if i == len(b.Path)-1 {
-			oMap[KBookmarkFullFileName] = pathOffsets[i] - 4
+			oMap[KBookmarkFullFileName] = buf.Len()
 		}
 		buf.Write(encodedStringItem(item))
 	}
@@ -99,20 +243,23 @@ func (b *BookmarkData) Write(w io.Writer) error {
 	padBuf(buf)
 
 	// each file ids for the path
-	cnidOffsets := make([]int, len(b.Path))
-	for i, cnid := range b.CNIDPath {
-		cnidOffsets[i] = 4 + buf.Len()
-		buf.Write(encodedUint64(cnid))
-	}
+	// path only bookmarks don't have a CNID path and resolve by path.
+	if len(b.CNIDPath) > 0 {
+		cnidOffsets := make([]int, len(b.CNIDPath))
+		for i, cnid := range b.CNIDPath {
+			cnidOffsets[i] = 4 + buf.Len()
+			buf.Write(encodedUint64(cnid))
+		}
 
-	// 0x05 0x10
-	oMap[KBookmarkCNIDPath] = buf.Len()
-	binary.Write(buf, binary.LittleEndian, uint32(len(b.CNIDPath)*4))
-	binary.Write(buf, binary.LittleEndian, uint32(bmk_array|bmk_st_one))
-	for _, offset := range cnidOffsets {
-		binary.Write(buf, binary.LittleEndian, uint32(offset))
+		// 0x05 0x10
+		oMap[KBookmarkCNIDPath] = buf.Len()
+		binary.Write(buf, binary.LittleEndian, uint32(len(b.CNIDPath)*4))
+		binary.Write(buf, binary.LittleEndian, uint32(bmk_array|bmk_st_one))
+		for _, offset := range cnidOffsets {
+			binary.Write(buf, binary.LittleEndian, uint32(offset))
+		}
+		padBuf(buf)
 	}
-	padBuf(buf)
 
 	// KBookmarkFileCreationDate 0x04 0x10
 	oMap[KBookmarkFileCreationDate] = buf.Len()
@@ -120,11 +267,11 @@ func (b *BookmarkData) Write(w io.Writer) error {
 	padBuf(buf)
 
 	// file ID 0x30 0x10
-	// if b.VolumeIsRoot {
-	// 	oMap[KBookmarkFileID] = buf.Len()
-	// 	buf.Write(encodedUint32(b.CNID))
-	// 	padBuf(buf)
-	// }
+	if b.CNID != 0 {
+		oMap[KBookmarkFileID] = buf.Len()
+		buf.Write(encodedUint64(b.CNID))
+		padBuf(buf)
+	}
 
 	// file properties
 	// 0x10 0x10
@@ -165,11 +312,30 @@ func (b *BookmarkData) Write(w io.Writer) error {
 	// KBookmarkVolumeURL 0x05 0x20
 	oMap[KBookmarkVolumeURL] = buf.Len()
 	binary.Write(buf, binary.LittleEndian, uint32(len(b.VolumeURL)))
-	// only support absolute path for now
-	binary.Write(buf, binary.LittleEndian, uint32(bmk_url|bmk_url_st_absolute))
+	if b.VolumeURLIsRelative {
+		binary.Write(buf, binary.LittleEndian, uint32(bmk_url|bmk_url_st_relative))
+	} else {
+		binary.Write(buf, binary.LittleEndian, uint32(bmk_url|bmk_url_st_absolute))
+	}
 	buf.Write([]byte(b.VolumeURL))
 	padBuf(buf)
 
+	// KBookmarkURLLengths 0x03 0xe0
+	if len(b.URLLengths) > 0 {
+		lengthOffsets := make([]int, len(b.URLLengths))
+		for i, length := range b.URLLengths {
+			lengthOffsets[i] = 4 + buf.Len()
+			buf.Write(encodedUint32(length))
+		}
+		oMap[KBookmarkURLLengths] = buf.Len()
+		binary.Write(buf, binary.LittleEndian, uint32(len(b.URLLengths)*4))
+		binary.Write(buf, binary.LittleEndian, uint32(bmk_array|bmk_st_one))
+		for _, offset := range lengthOffsets {
+			binary.Write(buf, binary.LittleEndian, uint32(offset))
+		}
+		padBuf(buf)
+	}
+
 	// KBookmarkVolumeName 0x10 0x20
 	oMap[KBookmarkVolumeName] = buf.Len()
 	buf.Write(encodedStringItem(b.VolumeName))
@@ -186,9 +352,12 @@ func (b *BookmarkData) Write(w io.Writer) error {
 	padBuf(buf)
 
 	// KBookmarkVolumeUUID 0x11 0x20
-	oMap[KBookmarkVolumeUUID] = buf.Len()
-	buf.Write(encodedStringItem(b.VolumeUUID))
-	padBuf(buf)
+	// Finder omits the UUID of volumes not having one (exFAT, FAT...)
+	if !isBlankUUID(b.VolumeUUID) {
+		oMap[KBookmarkVolumeUUID] = buf.Len()
+		buf.Write(encodedStringItem(b.VolumeUUID))
+		padBuf(buf)
+	}
 
 	// KBookmarkVolumeProperties 0x20 0x20
 	oMap[KBookmarkVolumeProperties] = buf.Len()
@@ -200,10 +369,25 @@ func (b *BookmarkData) Write(w io.Writer) error {
 	buf.Write(encodedStringItem(b.VolumePath))
 	padBuf(buf)
 
+	// KBookmarkVolumeMountPoint 0x50 0x20
+	if b.VolumeMountPoint != "" {
+		oMap[KBookmarkVolumeMountPoint] = buf.Len()
+		binary.Write(buf, binary.LittleEndian, uint32(len(b.VolumeMountPoint)))
+		binary.Write(buf, binary.LittleEndian, uint32(bmk_url|bmk_url_st_absolute))
+		buf.Write([]byte(b.VolumeMountPoint))
+		padBuf(buf)
+	}
+
+	// KBookmarkVolumeBookmark 0x40 0x20
+	if len(b.EmbeddedVolumeBookmark) > 0 {
+		oMap[KBookmarkVolumeBookmark] = buf.Len()
+		buf.Write(encodedBytes(b.EmbeddedVolumeBookmark))
+		padBuf(buf)
+	}
+
 	// KBookmarkFileType 0xf022
 	oMap[KBookmarkFileType] = buf.Len()
-	b.prepareTypeData()
-	buf.Write(encodedBytes(b.TypeData))
+	buf.Write(encodedBytes(b.typeData()))
 	padBuf(buf)
 
 	// 0x56 0x10 bool set to true
@@ -260,6 +444,21 @@ func (b *BookmarkData) Write(w io.Writer) error {
 		}
 	}
 
+	// unknown entries preserved from a decoded bookmark
+	for _, key := range b.unknownKeys() {
+		raw := b.Unknown[key]
+		if _, ok := oMap[key]; ok || len(raw) < 8 {
+			continue
+		}
+		switch binary.LittleEndian.Uint32(raw[4:]) & bmk_data_type_mask {
+		case bmk_array, bmk_dict:
+			continue
+		}
+		oMap[key] = buf.Len()
+		buf.Write(raw)
+		padBuf(buf)
+	}
+
 	// buffer the header now that we have enough data
 	hbuf := bytes.NewBufferString("book")
 	hbuf.Write(make([]byte, 4))
@@ -294,7 +493,12 @@ func (b *BookmarkData) Write(w io.Writer) error {
 	return err
 }
 
-func (b *BookmarkData) prepareTypeData() {
+// typeData returns the type data to write, the decoded one or, when the
+// bookmark doesn't have any, type data built from the target file extension.
+func (b *BookmarkData) typeData() []byte {
+	if len(b.TypeData) > 0 {
+		return b.TypeData
+	}
 	buf := &bytes.Buffer{}
 	buf.Write([]byte{
 		0x64, 0x6E, 0x69, 0x62, 0x00, 0x00, 0x00, 0x00,
@@ -310,7 +514,80 @@ func (b *BookmarkData) prepareTypeData() {
 	buf.Write([]byte(ext))
 	buf.Write([]byte{0x3f, 0x3f, 0x3f, 0x3f, 0x1})
 	buf.Write(make([]byte, 7))
-	b.TypeData = buf.Bytes()
+	return buf.Bytes()
+}
+
+// typeDataExtOffset is the offset of the length of the file extension in the
+// type data, the extension follows 8 bytes later.
+const typeDataExtOffset = 28
+
+// FileExtension returns the file extension of the target, without the leading
+// dot, as stored in the type data. The extension of the target path is
+// returned if the bookmark doesn't have type data.
+func (b *BookmarkData) FileExtension() string {
+	if len(b.TypeData) < typeDataExtOffset+8 {
+		return strings.TrimPrefix(filepath.Ext(b.TargetPath()), ".")
+	}
+	length := binary.LittleEndian.Uint32(b.TypeData[typeDataExtOffset:])
+	ext := b.TypeData[typeDataExtOffset+8:]
+	if uint64(length) > uint64(len(ext)) {
+		return ""
+	}
+	return string(ext[:length])
+}
+
+// Bytes returns the binary representation of the bookmark, as written by
+// Write.
+func (b *BookmarkData) Bytes() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := b.Write(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, see Bytes.
+func (b *BookmarkData) MarshalBinary() ([]byte, error) {
+	return b.Bytes()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler by decoding the
+// bookmark data like AliasFromReader and replacing the content of b.
+func (b *BookmarkData) UnmarshalBinary(data []byte) error {
+	decoded, err := AliasFromReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	*b = *decoded
+	return nil
+}
+
+// Equal reports whether both bookmarks point to the same target: same path,
+// CNID path, volume path, UUID and name and same creation date. The creation
+// date is compared to the second since it's stored as a float64 of seconds.
+// Nil and empty paths are equal. Other entries such as the volume properties
+// are ignored.
+func (b *BookmarkData) Equal(other *BookmarkData) bool {
+	if b == nil || other == nil {
+		return b == other
+	}
+	if len(b.Path) != len(other.Path) || len(b.CNIDPath) != len(other.CNIDPath) {
+		return false
+	}
+	for i := range b.Path {
+		if b.Path[i] != other.Path[i] {
+			return false
+		}
+	}
+	for i := range b.CNIDPath {
+		if b.CNIDPath[i] != other.CNIDPath[i] {
+			return false
+		}
+	}
+	return b.VolumePath == other.VolumePath &&
+		b.VolumeUUID == other.VolumeUUID &&
+		b.VolumeName == other.VolumeName &&
+		b.FileCreationDate.Truncate(time.Second).Equal(other.FileCreationDate.Truncate(time.Second))
 }
 
 func (b *BookmarkData) String() string {
@@ -337,23 +614,34 @@ func (oMap offsetMap) Bytes() []byte {
 	// Number of entries in this TOC
 	binary.Write(buf, binary.LittleEndian, uint32(len(oMap)))
 
-	// sort keys
-	keys := make([]int, len(oMap))
-	i := 0
-	for k := range oMap {
-		keys[i] = int(k)
-		i++
-	}
-	sort.Ints(keys)
-
-	for _, k := range keys {
+	for _, k := range oMap.keys() {
 		// key
-		binary.Write(buf, binary.LittleEndian, uint32(k))
+		binary.Write(buf, binary.LittleEndian, k)
 		// offset
-		binary.Write(buf, binary.LittleEndian, uint32(oMap[uint32(k)])+4)
+		binary.Write(buf, binary.LittleEndian, uint32(oMap[k])+4)
 		// reserved
 		binary.Write(buf, binary.LittleEndian, uint32(0))
 	}
 
 	return buf.Bytes()
 }
+
+// unknownKeys returns the sorted keys of the unknown entries so they are
+// written in a stable order.
+func (b *BookmarkData) unknownKeys() []uint32 {
+	keys := make(offsetMap, len(b.Unknown))
+	for key := range b.Unknown {
+		keys[key] = 0
+	}
+	return keys.keys()
+}
+
+// keys returns the sorted keys of the map.
+func (oMap offsetMap) keys() []uint32 {
+	keys := make([]uint32, 0, len(oMap))
+	for k := range oMap {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -2,8 +2,11 @@ package cocoa
 
 import (
 	"bytes"
+	"encoding"
+	"io/ioutil"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -18,7 +21,7 @@ func TestBookmarkData_Write(t *testing.T) {
 				FileSystemType:      "",
 				Path:                []string{"Users", "mattetti", "Splice", "sounds", "drums", "727 Maracas.wav"},
 				CNIDPath:            []uint64{0x669dc, 0x9b7c3, 0x2c2de1, 0x7f1e94, 0x8a2402, 0x8a2406},
-				FileCreationDate:    time.Unix(63190694952, 0),
+				FileCreationDate:    time.Unix(1488362952, 0),
 				FileProperties:      []uint8{0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xf, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0},
 				ContainingFolderIDX: 0x7,
 				VolumePath:          "/",
@@ -32,7 +35,7 @@ func TestBookmarkData_Write(t *testing.T) {
 				CreationOptions:     0x400,
 				WasFileReference:    true,
 				UserName:            "mattetti",
-				CNID:                0x8b4160,
+				CNID:                0x6010000000c, // 64 bit file ID, as on exFAT
 				UID:                 0x9942,
 				Filename:            "727 Maracas.wav",
 			},
@@ -57,9 +60,237 @@ func TestBookmarkData_Write(t *testing.T) {
 				t.Log("Saved failed generated alias to fixtures/failedTest.hex")
 				t.Fatal(err)
 			}
-			if reflect.DeepEqual(got, tt.data) {
+			if !got.Equal(tt.data) {
 				t.Errorf("BookmarkData didn't round trip, expected %v, got %v", tt.data, got)
 			}
+			if got.CNID != tt.data.CNID {
+				t.Errorf("CNID = %#x, want %#x", got.CNID, tt.data.CNID)
+			}
+			if got.FileExtension() != tt.data.FileExtension() {
+				t.Errorf("FileExtension() = %q, want %q", got.FileExtension(), tt.data.FileExtension())
+			}
+		})
+	}
+}
+
+func TestBookmarkData_FileExtension(t *testing.T) {
+	raw, err := ioutil.ReadFile("fixtures/relativeAlias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := AliasFromReader(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.FileExtension(); got != "png" {
+		t.Errorf("decoded FileExtension() = %q, want png", got)
+	}
+
+	b := &BookmarkData{VolumePath: "/", Path: []string{"Users", "matt", "sample.aiff"}}
+	if got := b.FileExtension(); got != "aiff" {
+		t.Errorf("FileExtension() without type data = %q, want aiff", got)
+	}
+	data, err := b.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.TypeData != nil {
+		t.Errorf("Bytes() modified the type data of the bookmark: %x", b.TypeData)
+	}
+	got, err := AliasFromReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.TypeData) == 0 {
+		t.Fatal("expected the type data to be decoded")
+	}
+	if ext := got.FileExtension(); ext != "aiff" {
+		t.Errorf("FileExtension() after a round trip = %q, want aiff", ext)
+	}
+
+	truncated := &BookmarkData{TypeData: append([]byte(nil), got.TypeData[:typeDataExtOffset+9]...)}
+	if ext := truncated.FileExtension(); ext != "" {
+		t.Errorf("FileExtension() with truncated type data = %q, want an empty string", ext)
+	}
+}
+
+func TestBookmarkData_MarshalBinary(t *testing.T) {
+	var _ encoding.BinaryMarshaler = &BookmarkData{}
+	var _ encoding.BinaryUnmarshaler = &BookmarkData{}
+
+	raw, err := ioutil.ReadFile("fixtures/alias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &BookmarkData{}
+	if err := b.UnmarshalBinary(raw); err != nil {
+		t.Fatalf("UnmarshalBinary() error = %v", err)
+	}
+	want, err := AliasFromReader(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(b, want) {
+		t.Errorf("UnmarshalBinary() = %#v, want %#v", b, want)
+	}
+
+	data, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := b.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, buf.Bytes()) {
+		t.Error("MarshalBinary() doesn't match the data written by Write")
+	}
+	got := &BookmarkData{Path: []string{"stale"}}
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary() error = %v", err)
+	}
+	if got.TargetPath() != b.TargetPath() {
+		t.Errorf("TargetPath() = %s, want %s", got.TargetPath(), b.TargetPath())
+	}
+
+	if err := got.UnmarshalBinary([]byte("not a bookmark")); err == nil {
+		t.Error("expected an error unmarshaling invalid data")
+	}
+}
+
+func TestBookmarkData_Equal(t *testing.T) {
+	base := func() *BookmarkData {
+		return &BookmarkData{
+			Path:             []string{"Users", "mattetti", "file.wav"},
+			CNIDPath:         []uint64{0x669dc, 0x9b7c3, 0x8a2406},
+			FileCreationDate: time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC),
+			VolumePath:       "/",
+			VolumeName:       "Macintosh HD",
+			VolumeUUID:       "0A81F3B1-51D9-3335-B3E3-169C3640360D",
+			VolumeProperties: []byte{0x81},
+		}
+	}
+	tests := []struct {
+		name   string
+		modify func(b *BookmarkData)
+		want   bool
+	}{
+		{"identical", func(b *BookmarkData) {}, true},
+		{"volume properties", func(b *BookmarkData) { b.VolumeProperties = []byte{0x81, 0, 0} }, true},
+		{"sub second date", func(b *BookmarkData) { b.FileCreationDate = b.FileCreationDate.Add(500 * time.Millisecond) }, true},
+		{"date", func(b *BookmarkData) { b.FileCreationDate = b.FileCreationDate.Add(time.Second) }, false},
+		{"path", func(b *BookmarkData) { b.Path[2] = "other.wav" }, false},
+		{"shorter path", func(b *BookmarkData) { b.Path = b.Path[:2] }, false},
+		{"cnid path", func(b *BookmarkData) { b.CNIDPath[2]++ }, false},
+		{"volume path", func(b *BookmarkData) { b.VolumePath = "/Volumes/Data" }, false},
+		{"volume name", func(b *BookmarkData) { b.VolumeName = "Data" }, false},
+		{"volume uuid", func(b *BookmarkData) { b.VolumeUUID = "" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := base()
+			tt.modify(b)
+			if got := base().Equal(b); got != tt.want {
+				t.Errorf("Equal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	empty := &BookmarkData{Path: []string{}, CNIDPath: []uint64{}}
+	if !empty.Equal(&BookmarkData{}) {
+		t.Error("expected empty and nil paths to be equal")
+	}
+	var nilBookmark *BookmarkData
+	if !nilBookmark.Equal(nil) || nilBookmark.Equal(empty) || empty.Equal(nil) {
+		t.Error("a nil bookmark should only be equal to nil")
+	}
+}
+
+func TestBookmarkData_TargetFingerprint(t *testing.T) {
+	const uuid = "0A81F3B1-51D9-3335-B3E3-169C3640360D"
+	a := &BookmarkData{
+		Path:       []string{"Users", "mattetti", "file.wav"},
+		CNIDPath:   []uint64{19, 409940, 8727353},
+		VolumeUUID: uuid,
+	}
+	// same file reached via a hard link somewhere else
+	b := &BookmarkData{
+		Path:       []string{"tmp", "link.wav"},
+		CNIDPath:   []uint64{2345, 8727353},
+		VolumeUUID: strings.ToLower(uuid),
+	}
+	other := &BookmarkData{
+		Path:       []string{"Users", "mattetti", "file.wav"},
+		CNIDPath:   []uint64{19, 409940, 8727354},
+		VolumeUUID: uuid,
+	}
+	otherVolume := &BookmarkData{
+		CNIDPath:   []uint64{19, 409940, 8727353},
+		VolumeUUID: "E4AAC9E6-F1D3-3D37-AB1C-0C0A2A6B8CD1",
+	}
+
+	fp := a.TargetFingerprint()
+	if len(fp) != 64 {
+		t.Fatalf("unexpected fingerprint %q", fp)
+	}
+	if got := b.TargetFingerprint(); got != fp {
+		t.Errorf("expected the same fingerprint for the same file, got %s and %s", got, fp)
+	}
+	if other.TargetFingerprint() == fp {
+		t.Error("expected a different fingerprint for a different CNID")
+	}
+	if otherVolume.TargetFingerprint() == fp {
+		t.Error("expected a different fingerprint for a different volume")
+	}
+	if got := (&BookmarkData{Path: a.Path}).TargetFingerprint(); got != "" {
+		t.Errorf("expected no fingerprint without CNID, got %s", got)
+	}
+}
+
+func TestBookmarkData_volumeRelativePath(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       []string
+		volumePath string
+		want       []string
+	}{
+		{"root volume", []string{"Users", "mattetti", "file.wav"}, "/", []string{"Users", "mattetti", "file.wav"}},
+		{"external volume", []string{"Volumes", "MattSplice", "file.wav"}, "/Volumes/MattSplice", []string{"file.wav"}},
+		{"path outside of the volume", []string{"Users", "file.wav"}, "/Volumes/MattSplice", []string{"Users", "file.wav"}},
+		{"volume itself", []string{"Volumes", "MattSplice"}, "/Volumes/MattSplice", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BookmarkData{Path: tt.path, VolumePath: tt.volumePath}
+			if got := b.volumeRelativePath(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("volumeRelativePath() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBookmarkData_TargetPath(t *testing.T) {
+	tests := []struct {
+		name       string
+		volumePath string
+		path       []string
+		want       string
+	}{
+		// the decoded path components include the mount point of the volume
+		{"root volume", "/", []string{"Users", "file.wav"}, "/Users/file.wav"},
+		{"external volume", "/Volumes/Disk", []string{"Volumes", "Disk", "file.wav"}, "/Volumes/Disk/file.wav"},
+		{"trailing slash", "/Volumes/Disk/", []string{"Volumes", "Disk", "file.wav"}, "/Volumes/Disk/file.wav"},
+		{"extra slashes", "/Volumes/Disk//", []string{"Volumes", "Disk", "folder/", "file.wav"}, "/Volumes/Disk/folder/file.wav"},
+		{"volume itself", "/Volumes/Disk", []string{"Volumes", "Disk"}, "/Volumes/Disk"},
+		{"volume relative path", "/Volumes/Disk", []string{"folder", "file.wav"}, "/Volumes/Disk/folder/file.wav"},
+		{"no path", "/Volumes/Disk", nil, "/Volumes/Disk"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BookmarkData{VolumePath: tt.volumePath, Path: tt.path}
+			if got := b.TargetPath(); got != tt.want {
+				t.Errorf("TargetPath() = %s, want %s", got, tt.want)
+			}
 		})
 	}
 }
@@ -3,14 +3,47 @@ package cocoa
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"time"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/mattetti/cocoa/darwin"
 )
 
+// ParseOptions are the options used when parsing bookmark data.
+type ParseOptions struct {
+	// ContinueOnError keeps decoding the other entries when an entry fails to
+	// decode. The errors are collected in BookmarkData.DecodeErrors.
+	// By default, decoding stops at the first error.
+	ContinueOnError bool
+	// ValidateUTF8 makes the decoding fail with ErrInvalidUTF8 if a path
+	// component isn't valid UTF-8.
+	ValidateUTF8 bool
+}
+
+// ErrInvalidUTF8 is returned when a decoded path component isn't valid UTF-8
+// and ParseOptions.ValidateUTF8 is set.
+var ErrInvalidUTF8 = errors.New("invalid UTF-8")
+
+// validateUTF8 checks that all the path components of the bookmark are valid
+// UTF-8.
+func validateUTF8(b *BookmarkData) error {
+	for i, component := range b.Path {
+		if !utf8.ValidString(component) {
+			return fmt.Errorf("%w in path component %d: %q", ErrInvalidUTF8, i, component)
+		}
+	}
+	if !utf8.ValidString(b.Filename) {
+		return fmt.Errorf("%w in the file name: %q", ErrInvalidUTF8, b.Filename)
+	}
+	return nil
+}
+
 func newBookmarkDecoder(r io.Reader) (*bookmarkDecoder, error) {
 	data, err := ioutil.ReadAll(r)
 	if err != nil {
@@ -23,8 +56,15 @@ func newBookmarkDecoder(r io.Reader) (*bookmarkDecoder, error) {
 	}, nil
 }
 
+// bookmarkReader is the data the decoder works on, a bytes.Reader for
+// buffered data or an io.SectionReader reading straight from an io.ReaderAt.
+type bookmarkReader interface {
+	io.ReadSeeker
+	Size() int64
+}
+
 type bookmarkDecoder struct {
-	r          *bytes.Reader
+	r          bookmarkReader
 	pos        int64
 	err        error
 	b          *BookmarkData
@@ -57,27 +97,99 @@ func (d *bookmarkDecoder) aliasHeader() error {
 	if d.pos != int64(d.headerSize) {
 		return fmt.Errorf("header size didn't match expectations, at %d - %d", d.pos, d.headerSize)
 	}
+	if d.err != nil {
+		return d.err
+	}
+	size := d.r.Size()
+	if size < int64(d.headerSize) {
+		return fmt.Errorf("invalid bookmark file - %d bytes is too short for the %d bytes header", size, d.headerSize)
+	}
+	if int64(d.bodySize) > size-int64(d.headerSize) {
+		return fmt.Errorf("invalid bookmark file - body size %d exceeds the %d bytes following the header",
+			d.bodySize, size-int64(d.headerSize))
+	}
+	return nil
+}
+
+// tocHeaderSize is the size of the TOC fields preceding its entries.
+const tocHeaderSize = 20
+
+// seekTOC reads the offset of the TOC, found at the start of the body, and
+// moves to it.
+func (d *bookmarkDecoder) seekTOC() error {
+	d.read(&d.tocOffset)
+	if d.err != nil {
+		return d.err
+	}
+	return d.seekTOCAt(d.tocOffset)
+}
+
+// seekTOCAt moves to the TOC found at the passed offset, relative to the
+// start of the body.
+func (d *bookmarkDecoder) seekTOCAt(offset uint32) error {
+	tocPos := int64(d.headerSize) + int64(offset)
+	if tocPos+tocHeaderSize > d.r.Size() {
+		return fmt.Errorf("invalid bookmark file - TOC offset %d is past the end of the %d bytes body",
+			offset, d.r.Size()-int64(d.headerSize))
+	}
+	d.seek(tocPos, io.SeekStart)
 	return d.err
 }
 
+// toc reads the TOC at the current position and the TOCs chained to it by
+// their next TOC offset, security scoped bookmarks for instance have more
+// than one. The entries of the first TOCs win over the same keys found in
+// the following ones.
 func (d *bookmarkDecoder) toc() error {
+	d.oMap = offsetMap{}
+	visited := map[uint32]bool{d.tocOffset: true}
+	for {
+		next, err := d.readTOC()
+		if err != nil {
+			return err
+		}
+		if next == 0 {
+			return nil
+		}
+		if visited[next] {
+			return fmt.Errorf("TOC at offset %d was already read, the TOCs form a cycle", next)
+		}
+		visited[next] = true
+		if err := d.seekTOCAt(next); err != nil {
+			return err
+		}
+	}
+}
+
+// readTOC reads the TOC at the current position, adds its entries to oMap
+// and returns the offset of the next TOC, 0 if there isn't any.
+func (d *bookmarkDecoder) readTOC() (next uint32, err error) {
 	// Size of TOC in bytes, minus 8
 	var tocSize uint32
 	d.read(&tocSize)
+	if err := d.checkLength("TOC", tocSize); err != nil {
+		return 0, err
+	}
 	// magic number
 	tmp := make([]byte, 4)
 	d.read(&tmp)
 	if bytes.Compare(tmp, []byte{0xFE, 0xFF, 0xFF, 0xFF}) != 0 {
-		return fmt.Errorf("bad TOC")
+		return 0, fmt.Errorf("bad TOC")
 	}
-	// skip
-	d.seek(4+4, io.SeekCurrent)
 	// identifier uint32(1)
+	d.seek(4, io.SeekCurrent)
 	// Next TOC offset (or uint32(0) if none)
+	d.read(&next)
 	// Number of entries in this TOC
 	var nItems uint32
 	d.read(&nItems)
-	d.oMap = offsetMap{}
+	if d.err != nil {
+		return 0, d.err
+	}
+	// each entry is 12 bytes long
+	if remaining := d.remaining(); int64(nItems)*12 > remaining {
+		return 0, fmt.Errorf("TOC of %d entries exceeds the remaining %d bytes", nItems, remaining)
+	}
 	var key uint32
 	var offset uint32
 	for i := uint32(0); i < nItems; i++ {
@@ -87,10 +199,12 @@ func (d *bookmarkDecoder) toc() error {
 		d.read(&offset)
 		// blank
 		d.seek(4, io.SeekCurrent)
-		d.oMap[key] = int(offset + d.headerSize) // set absolute position
+		if _, ok := d.oMap[key]; !ok {
+			d.oMap[key] = int(offset + d.headerSize) // set absolute position
+		}
 	}
 
-	return d.err
+	return next, d.err
 }
 
 func (d *bookmarkDecoder) decodeStringSlice() ([]string, error) {
@@ -105,6 +219,9 @@ func (d *bookmarkDecoder) decodeStringSlice() ([]string, error) {
 	if dType != bmk_array {
 		return nil, fmt.Errorf("unexpected array type, expected %#x got %#x", bmk_array, typeMask)
 	}
+	if err := d.checkLength("array", size); err != nil {
+		return nil, err
+	}
 
 	nItems := size / 4
 	offsets := make([]uint32, nItems)
@@ -135,6 +252,9 @@ func (d *bookmarkDecoder) decodeUint32Slice() ([]uint32, error) {
 	if dType != bmk_array {
 		return nil, fmt.Errorf("unexpected array type, expected %#x got %#x", bmk_array, typeMask)
 	}
+	if err := d.checkLength("array", size); err != nil {
+		return nil, err
+	}
 
 	nItems := size / 4
 	items := make([]uint32, nItems)
@@ -144,20 +264,103 @@ func (d *bookmarkDecoder) decodeUint32Slice() ([]uint32, error) {
 	return items, d.err
 }
 
+// decodeUint32 decodes a number stored as a 32 bit integer.
 func (d *bookmarkDecoder) decodeUint32() (uint32, error) {
+	n, err := d.decodeNumber()
+	if err != nil {
+		return 0, err
+	}
+	i, ok := n.(int32)
+	if !ok {
+		return 0, fmt.Errorf("unexpected number subtype, expected a 32 bit integer got %T", n)
+	}
+	return uint32(i), nil
+}
+
+// decodeUint64 decodes a 32 or 64 bit integer such as a file ID.
+func (d *bookmarkDecoder) decodeUint64() (uint64, error) {
+	n, err := d.decodeNumber()
+	if err != nil {
+		return 0, err
+	}
+	switch i := n.(type) {
+	case int32:
+		return uint64(uint32(i)), nil
+	case int64:
+		return uint64(i), nil
+	}
+	return 0, fmt.Errorf("unexpected number subtype, expected a 32 or 64 bit integer got %T", n)
+}
+
+// decodeNumber decodes the number at the current position into an int8,
+// int16, int32, int64, float32 or float64 depending on its subtype.
+func (d *bookmarkDecoder) decodeNumber() (interface{}, error) {
 	var len uint32
 	var typeMask uint32
 	d.read(&len)
 	d.read(&typeMask)
 	dType := typeMask & bmk_data_type_mask
-	// dSubType := typeMask & bmk_data_subtype_mask
+	if dType != bmk_number {
+		return nil, fmt.Errorf("unexpected number type, expected %d got %d", bmk_number, typeMask)
+	}
+
+	switch dSubType := typeMask & bmk_data_subtype_mask; dSubType {
+	case darwin.KCFNumberSInt8Type:
+		var n int8
+		d.read(&n)
+		return n, d.err
+	case darwin.KCFNumberSInt16Type:
+		var n int16
+		d.read(&n)
+		return n, d.err
+	case darwin.KCFNumberSInt32Type:
+		var n int32
+		d.read(&n)
+		return n, d.err
+	case darwin.KCFNumberSInt64Type:
+		var n int64
+		d.read(&n)
+		return n, d.err
+	case darwin.KCFNumberFloat32Type:
+		var n float32
+		d.read(&n)
+		return n, d.err
+	case darwin.KCFNumberFloat64Type:
+		var n float64
+		d.read(&n)
+		return n, d.err
+	default:
+		return nil, fmt.Errorf("unsupported number subtype %d", dSubType)
+	}
+}
 
+// decodeIndex decodes a number stored as a signed 32 or 64 bit integer into
+// an uint32, failing if the value doesn't fit.
+func (d *bookmarkDecoder) decodeIndex() (uint32, error) {
+	var len uint32
+	var typeMask uint32
+	d.read(&len)
+	d.read(&typeMask)
+	dType := typeMask & bmk_data_type_mask
 	if dType != bmk_number {
 		return 0, fmt.Errorf("unexpected number type, expected %d got %d", bmk_number, typeMask)
 	}
-	var n uint32
-	d.read(&n)
-	return n, d.err
+
+	switch dSubType := typeMask & bmk_data_subtype_mask; dSubType {
+	case darwin.KCFNumberSInt32Type:
+		var n uint32
+		d.read(&n)
+		return n, d.err
+	case darwin.KCFNumberSInt64Type:
+		var n uint64
+		d.read(&n)
+		if n > math.MaxUint32 {
+			return 0, fmt.Errorf("index %d overflows an uint32", n)
+		}
+		return uint32(n), d.err
+	default:
+		return 0, fmt.Errorf("unexpected number subtype, expected a 32 or 64 bit integer got %d", dSubType)
+	}
 }
 
 func (d *bookmarkDecoder) decodeInt64() (int64, error) {
@@ -192,6 +395,41 @@ func (d *bookmarkDecoder) decodeBool() (bool, error) {
 	return false, d.err
 }
 
+// decodeUUID returns the 16 bytes of the UUID record at the current position.
+func (d *bookmarkDecoder) decodeUUID() ([16]byte, error) {
+	var uuid [16]byte
+	var len uint32
+	var typeMask uint32
+	d.read(&len)
+	d.read(&typeMask)
+	if typeMask&bmk_data_type_mask != bmk_uuid {
+		return uuid, fmt.Errorf("unexpected uuid type, expected %d got %d", bmk_uuid, typeMask)
+	}
+	if len != 16 {
+		return uuid, fmt.Errorf("invalid uuid length %d", len)
+	}
+	d.read(&uuid)
+	return uuid, d.err
+}
+
+// decodeURL returns the URL of the record at the current position and
+// whether it's relative to another location.
+func (d *bookmarkDecoder) decodeURL() (string, bool, error) {
+	var len uint32
+	var typeMask uint32
+	d.read(&len)
+	d.read(&typeMask)
+	if typeMask&bmk_data_type_mask != bmk_url {
+		return "", false, fmt.Errorf("unexpected url type, expected %d got %d", bmk_url, typeMask)
+	}
+	if err := d.checkLength("url", len); err != nil {
+		return "", false, err
+	}
+	urlB := make([]byte, len)
+	d.read(&urlB)
+	return string(urlB), typeMask&bmk_data_subtype_mask == bmk_url_st_relative, d.err
+}
+
 func (d *bookmarkDecoder) decodeString() (string, error) {
 	var len uint32
 	var typeMask uint32
@@ -201,9 +439,26 @@ func (d *bookmarkDecoder) decodeString() (string, error) {
 	if dType != bmk_string {
 		return "", fmt.Errorf("unexpected string type, expected %d got %d", bmk_string, typeMask)
 	}
+	if err := d.checkLength("string", len); err != nil {
+		return "", err
+	}
 	strB := make([]byte, len)
 	d.read(&strB)
-	return string(strB), nil
+	dSubType := typeMask & bmk_data_subtype_mask
+	if dSubType == bmk_string_st_utf8 {
+		return string(strB), nil
+	}
+	if dSubType != bmk_string_st_utf16 {
+		return "", fmt.Errorf("unsupported string subtype %d", dSubType)
+	}
+	if len%2 != 0 {
+		return "", fmt.Errorf("invalid UTF-16 string length %d", len)
+	}
+	units := make([]uint16, len/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(strB[i*2:])
+	}
+	return string(utf16.Decode(units)), nil
 }
 
 func (d *bookmarkDecoder) decodeBytes() ([]byte, error) {
@@ -215,11 +470,171 @@ func (d *bookmarkDecoder) decodeBytes() ([]byte, error) {
 	if dType != bmk_data {
 		return nil, fmt.Errorf("unexpected byte type, expected %d got %d", bmk_data, typeMask)
 	}
+	if err := d.checkLength("data", len); err != nil {
+		return nil, err
+	}
 	data := make([]byte, len)
 	d.read(&data)
 	return data, d.err
 }
 
+// maxValueDepth limits the nesting of the arrays and dictionaries decoded by
+// decodeValue, offsets pointing back to a parent would otherwise loop forever.
+const maxValueDepth = 16
+
+// decodeValue decodes the record at the current position, whatever its type,
+// into a string, []byte, number (see decodeNumber), time.Time, bool,
+// []interface{}, map[interface{}]interface{} or nil for null records. UUIDs
+// and URLs are returned as strings.
+func (d *bookmarkDecoder) decodeValue(depth int) (interface{}, error) {
+	if depth > maxValueDepth {
+		return nil, fmt.Errorf("values nested more than %d levels deep", maxValueDepth)
+	}
+	var len uint32
+	var typeMask uint32
+	d.read(&len)
+	d.read(&typeMask)
+	if d.err != nil {
+		return nil, d.err
+	}
+	// the typed decoders read the record header themselves
+	d.seek(-8, io.SeekCurrent)
+	switch typeMask & bmk_data_type_mask {
+	case bmk_string:
+		return d.decodeString()
+	case bmk_data:
+		return d.decodeBytes()
+	case bmk_number:
+		return d.decodeNumber()
+	case bmk_date:
+		return d.decodeTime()
+	case bmk_boolean:
+		return d.decodeBool()
+	case bmk_uuid:
+		uuid, err := d.decodeUUID()
+		return uuidString(uuid), err
+	case bmk_url:
+		url, _, err := d.decodeURL()
+		return url, err
+	case bmk_null:
+		return nil, nil
+	case bmk_array:
+		return d.decodeArray(depth)
+	case bmk_dict:
+		return d.decodeDict(depth)
+	}
+	return nil, fmt.Errorf("unknown record type %#x", typeMask)
+}
+
+// decodeOffsets reads the header of the container record at the current
+// position and returns the offsets of its items.
+func (d *bookmarkDecoder) decodeOffsets(kind string, dType uint32) ([]uint32, error) {
+	var size uint32
+	var typeMask uint32
+	d.read(&size)
+	d.read(&typeMask)
+	if typeMask&bmk_data_type_mask != dType {
+		return nil, fmt.Errorf("unexpected %s type, expected %#x got %#x", kind, dType, typeMask)
+	}
+	if err := d.checkLength(kind, size); err != nil {
+		return nil, err
+	}
+	offsets := make([]uint32, size/4)
+	for i := range offsets {
+		d.read(&offsets[i])
+	}
+	return offsets, d.err
+}
+
+// decodeArray decodes the array at the current position and the values it
+// references.
+func (d *bookmarkDecoder) decodeArray(depth int) ([]interface{}, error) {
+	offsets, err := d.decodeOffsets("array", bmk_array)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]interface{}, len(offsets))
+	for i, offset := range offsets {
+		d.seek(int64(d.headerSize+offset), io.SeekStart)
+		if items[i], err = d.decodeValue(depth + 1); err != nil {
+			return items, fmt.Errorf("failed to read the %d value in array - %v", i, err)
+		}
+	}
+	return items, nil
+}
+
+// decodeDict decodes the dictionary at the current position, stored as key
+// and value offset pairs, and the keys and values it references.
+func (d *bookmarkDecoder) decodeDict(depth int) (map[interface{}]interface{}, error) {
+	offsets, err := d.decodeOffsets("dictionary", bmk_dict)
+	if err != nil {
+		return nil, err
+	}
+	if len(offsets)%2 != 0 {
+		return nil, fmt.Errorf("dictionary with %d offsets isn't made of key value pairs", len(offsets))
+	}
+	dict := make(map[interface{}]interface{}, len(offsets)/2)
+	for i := 0; i < len(offsets); i += 2 {
+		d.seek(int64(d.headerSize+offsets[i]), io.SeekStart)
+		key, err := d.decodeValue(depth + 1)
+		if err != nil {
+			return dict, fmt.Errorf("failed to read the %d key in dictionary - %v", i/2, err)
+		}
+		switch key.(type) {
+		case []byte, []interface{}, map[interface{}]interface{}:
+			return dict, fmt.Errorf("unsupported dictionary key type %T", key)
+		}
+		d.seek(int64(d.headerSize+offsets[i+1]), io.SeekStart)
+		if dict[key], err = d.decodeValue(depth + 1); err != nil {
+			return dict, fmt.Errorf("failed to read the value of %v in dictionary - %v", key, err)
+		}
+	}
+	return dict, nil
+}
+
+// decodeRaw returns the raw bytes of the record at the current position,
+// including its length and type header.
+func (d *bookmarkDecoder) decodeRaw() ([]byte, error) {
+	var len uint32
+	var typeMask uint32
+	d.read(&len)
+	d.read(&typeMask)
+	if d.err != nil {
+		return nil, d.err
+	}
+	if err := d.checkLength("record", len); err != nil {
+		return nil, err
+	}
+	raw := make([]byte, 8+len)
+	binary.LittleEndian.PutUint32(raw, len)
+	binary.LittleEndian.PutUint32(raw[4:], typeMask)
+	data := raw[8:]
+	d.read(&data)
+	return raw, d.err
+}
+
+// checkLength makes sure the declared length of the record being decoded
+// doesn't exceed the remaining data, so corrupt lengths fail before
+// allocating.
+func (d *bookmarkDecoder) checkLength(kind string, length uint32) error {
+	if d.err != nil {
+		return d.err
+	}
+	if remaining := d.remaining(); int64(length) > remaining {
+		return fmt.Errorf("%s length %d exceeds the remaining %d bytes", kind, length, remaining)
+	}
+	return nil
+}
+
+// remaining returns the number of bytes left after the current position.
+func (d *bookmarkDecoder) remaining() int64 {
+	pos, err := d.r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0
+	}
+	return d.r.Size() - pos
+}
+
 func (d *bookmarkDecoder) decodeTime() (time.Time, error) {
 	var len uint32
 	var typeMask uint32
@@ -0,0 +1,380 @@
+package cocoa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/mattetti/cocoa/darwin"
+)
+
+func Test_bookmarkDecoder_decodeIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    uint32
+		wantErr bool
+	}{
+		{name: "32 bit", data: encodedUint32(7), want: 7},
+		{name: "64 bit", data: encodedUint64(7), want: 7},
+		{name: "large 64 bit", data: encodedUint64(0xfffffff0), want: 0xfffffff0},
+		{name: "overflow", data: encodedUint64(1 << 40), wantErr: true},
+		{name: "not a number", data: encodedStringItem("7"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &bookmarkDecoder{r: bytes.NewReader(tt.data)}
+			got, err := d.decodeIndex()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("bookmarkDecoder.decodeIndex() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("bookmarkDecoder.decodeIndex() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// encodedNumber encodes n as a bookmark number of the passed subtype.
+func encodedNumber(subType uint32, n interface{}) []byte {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint32(buf, uint32(binary.Size(n)))
+	binary.LittleEndian.PutUint32(buf[4:], bmk_number|subType)
+	w := bytes.NewBuffer(buf)
+	binary.Write(w, binary.LittleEndian, n)
+	return w.Bytes()
+}
+
+func Test_bookmarkDecoder_decodeNumber(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "SInt8", data: encodedNumber(darwin.KCFNumberSInt8Type, int8(-7)), want: int8(-7)},
+		{name: "SInt16", data: encodedNumber(darwin.KCFNumberSInt16Type, int16(-700)), want: int16(-700)},
+		{name: "SInt32", data: encodedUint32(7), want: int32(7)},
+		{name: "SInt64", data: encodedUint64(1 << 40), want: int64(1 << 40)},
+		{name: "Float32", data: encodedNumber(darwin.KCFNumberFloat32Type, float32(1.5)), want: float32(1.5)},
+		{name: "Float64", data: encodedNumber(darwin.KCFNumberFloat64Type, 44100.5), want: 44100.5},
+		{name: "unsupported subtype", data: encodedNumber(darwin.KCFNumberCGFloatType, 1.5), wantErr: true},
+		{name: "not a number", data: encodedStringItem("7"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &bookmarkDecoder{r: bytes.NewReader(tt.data)}
+			got, err := d.decodeNumber()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("bookmarkDecoder.decodeNumber() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("bookmarkDecoder.decodeNumber() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	// decodeUint32 only accepts 32 bit integers
+	d := &bookmarkDecoder{r: bytes.NewReader(encodedUint32(0xfffffff0))}
+	if n, err := d.decodeUint32(); err != nil || n != 0xfffffff0 {
+		t.Errorf("bookmarkDecoder.decodeUint32() = %d, %v, want %d", n, err, uint32(0xfffffff0))
+	}
+	d = &bookmarkDecoder{r: bytes.NewReader(encodedNumber(darwin.KCFNumberFloat64Type, 7.0))}
+	if _, err := d.decodeUint32(); err == nil {
+		t.Error("bookmarkDecoder.decodeUint32() expected an error for a float")
+	}
+}
+
+// encodedUTF16StringItem encodes the string as a UTF-16 bookmark string.
+func encodedUTF16StringItem(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	buf := make([]byte, 8+len(units)*2)
+	binary.LittleEndian.PutUint32(buf, uint32(len(units)*2))
+	binary.LittleEndian.PutUint32(buf[4:], uint32(bmk_string|bmk_string_st_utf16))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(buf[8+i*2:], u)
+	}
+	return buf
+}
+
+func Test_bookmarkDecoder_decodeString(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr bool
+	}{
+		{name: "UTF-8", data: encodedStringItem("Café"), want: "Café"},
+		{name: "UTF-16", data: encodedUTF16StringItem("Café 音楽"), want: "Café 音楽"},
+		{name: "UTF-16 surrogate pair", data: encodedUTF16StringItem("🎵.wav"), want: "🎵.wav"},
+		{name: "odd UTF-16 length", data: []byte{3, 0, 0, 0, 2, 1, 0, 0, 'a', 0, 'b'}, wantErr: true},
+		{name: "not a string", data: encodedUint32(7), wantErr: true},
+		{name: "no subtype", data: []byte{2, 0, 0, 0, 0, 1, 0, 0, 'a', 0}, wantErr: true},
+		{name: "unknown subtype", data: []byte{2, 0, 0, 0, 3, 1, 0, 0, 'a', 0}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &bookmarkDecoder{r: bytes.NewReader(tt.data)}
+			got, err := d.decodeString()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("bookmarkDecoder.decodeString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("bookmarkDecoder.decodeString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_bookmarkDecoder_decodeStringSlice_utf16(t *testing.T) {
+	// path array with a single item stored right after it
+	data := []byte{4, 0, 0, 0, 0x01, 0x06, 0, 0, 12, 0, 0, 0}
+	data = append(data, encodedUTF16StringItem("Préférences")...)
+	d := &bookmarkDecoder{r: bytes.NewReader(data)}
+	got, err := d.decodeStringSlice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != "Préférences" {
+		t.Errorf("bookmarkDecoder.decodeStringSlice() = %q, want [Préférences]", got)
+	}
+}
+
+func Test_bookmarkDecoder_decodeUUID(t *testing.T) {
+	uuid := [16]byte{0x0a, 0x81, 0xf3, 0xb1, 0x51, 0xd9, 0x33, 0x35, 0xb3, 0xe3, 0x16, 0x9c, 0x36, 0x40, 0x36, 0x0d}
+	tests := []struct {
+		name    string
+		data    []byte
+		want    [16]byte
+		wantErr bool
+	}{
+		{name: "uuid", data: encodedUUID(uuid), want: uuid},
+		{name: "wrong length", data: append([]byte{8, 0, 0, 0, 1, 8, 0, 0}, uuid[:8]...), wantErr: true},
+		{name: "string", data: encodedStringItem("0A81F3B1-51D9-3335-B3E3-169C3640360D"), wantErr: true},
+		{name: "truncated", data: encodedUUID(uuid)[:20], wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &bookmarkDecoder{r: bytes.NewReader(tt.data)}
+			got, err := d.decodeUUID()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("bookmarkDecoder.decodeUUID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("bookmarkDecoder.decodeUUID() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+	if got, want := uuidString(uuid), "0A81F3B1-51D9-3335-B3E3-169C3640360D"; got != want {
+		t.Errorf("uuidString() = %s, want %s", got, want)
+	}
+}
+
+// encodedContainer encodes an array or dictionary record found at base and
+// the records it references, stored right after it.
+func encodedContainer(base int, dType uint32, items ...[]byte) []byte {
+	header := make([]byte, 8+4*len(items))
+	binary.LittleEndian.PutUint32(header, uint32(4*len(items)))
+	binary.LittleEndian.PutUint32(header[4:], dType|bmk_st_one)
+	offset := base + len(header)
+	var body []byte
+	for i, item := range items {
+		binary.LittleEndian.PutUint32(header[8+4*i:], uint32(offset))
+		offset += len(item)
+		body = append(body, item...)
+	}
+	return append(header, body...)
+}
+
+func Test_bookmarkDecoder_decodeDict(t *testing.T) {
+	name, value, nestedKey := encodedStringItem("name"), encodedStringItem("cocoa"), encodedStringItem("nested")
+	dictSize := 8 + 4*4
+	nested := encodedContainer(dictSize+len(name)+len(value)+len(nestedKey), bmk_array,
+		encodedUint32(7), encodedBool(true))
+	data := encodedContainer(0, bmk_dict, name, value, nestedKey, nested)
+
+	d := &bookmarkDecoder{r: bytes.NewReader(data)}
+	got, err := d.decodeDict(0)
+	if err != nil {
+		t.Fatalf("bookmarkDecoder.decodeDict() error = %v", err)
+	}
+	want := map[interface{}]interface{}{
+		"name":   "cocoa",
+		"nested": []interface{}{int32(7), true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bookmarkDecoder.decodeDict() = %#v, want %#v", got, want)
+	}
+
+	// decoded as an unknown TOC entry
+	d = &bookmarkDecoder{r: bytes.NewReader(data), b: &BookmarkData{}}
+	if err := d.decodeEntry(0xf081, 0); err != nil {
+		t.Fatalf("bookmarkDecoder.decodeEntry() error = %v", err)
+	}
+	if !reflect.DeepEqual(d.b.Extras[0xf081], want) {
+		t.Errorf("Extras[0xf081] = %#v, want %#v", d.b.Extras[0xf081], want)
+	}
+	if _, ok := d.b.Unknown[0xf081]; !ok {
+		t.Error("expected the raw dictionary to be kept in Unknown")
+	}
+
+	// a value pointing back to the dictionary itself
+	loop := encodedContainer(0, bmk_dict, name)
+	loop = append(loop[:8], 8+8, 0, 0, 0, 0, 0, 0, 0)
+	loop = append(loop, name...)
+	binary.LittleEndian.PutUint32(loop, 8)
+	d = &bookmarkDecoder{r: bytes.NewReader(loop)}
+	if _, err := d.decodeDict(0); err == nil {
+		t.Error("expected an error decoding a dictionary referencing itself")
+	}
+	d = &bookmarkDecoder{r: bytes.NewReader(loop), b: &BookmarkData{}}
+	if err := d.decodeEntry(0xf081, 0); err != nil {
+		t.Fatalf("bookmarkDecoder.decodeEntry() error = %v, expected the invalid dictionary to be skipped", err)
+	}
+	if _, ok := d.b.Extras[0xf081]; ok {
+		t.Error("expected the invalid dictionary not to be added to Extras")
+	}
+	if _, ok := d.b.Unknown[0xf081]; !ok {
+		t.Error("expected the raw invalid dictionary to be kept in Unknown")
+	}
+	// odd number of offsets
+	d = &bookmarkDecoder{r: bytes.NewReader(encodedContainer(0, bmk_dict, name))}
+	if _, err := d.decodeDict(0); err == nil {
+		t.Error("expected an error decoding a dictionary without a value")
+	}
+}
+
+func Test_bookmarkDecoder_lengthBounds(t *testing.T) {
+	// declares a 1GB payload
+	header := []byte{0, 0, 0, 0x40, 0, 0, 0, 0}
+	tests := []struct {
+		name   string
+		typ    uint32
+		decode func(d *bookmarkDecoder) error
+	}{
+		{"string", bmk_string | bmk_st_one, func(d *bookmarkDecoder) error { _, err := d.decodeString(); return err }},
+		{"data", bmk_data | bmk_st_one, func(d *bookmarkDecoder) error { _, err := d.decodeBytes(); return err }},
+		{"url", bmk_url | bmk_url_st_absolute, func(d *bookmarkDecoder) error { _, _, err := d.decodeURL(); return err }},
+		{"string array", bmk_array | bmk_st_one, func(d *bookmarkDecoder) error { _, err := d.decodeStringSlice(); return err }},
+		{"uint32 array", bmk_array | bmk_st_one, func(d *bookmarkDecoder) error { _, err := d.decodeUint32Slice(); return err }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := append([]byte{}, header...)
+			binary.LittleEndian.PutUint32(data[4:], tt.typ)
+			data = append(data, "truncated"...)
+			d := &bookmarkDecoder{r: bytes.NewReader(data)}
+			err := tt.decode(d)
+			if err == nil || !strings.Contains(err.Error(), "exceeds the remaining 9 bytes") {
+				t.Errorf("expected a length error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAliasFromReader_truncated(t *testing.T) {
+	for _, fixture := range []string{"fixtures/alias", "fixtures/exFATAlias", "fixtures/diskImageAlias", "fixtures/relativeAlias"} {
+		data, err := ioutil.ReadFile(fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// every truncation must fail with an error instead of panicking or
+		// hanging
+		for i := 0; i < len(data); i++ {
+			if _, err := AliasFromReader(bytes.NewReader(data[:i])); err == nil {
+				t.Errorf("%s truncated to %d bytes decoded without error", fixture, i)
+			}
+			if _, err := BookmarkKeys(data[:i]); err == nil {
+				t.Errorf("%s truncated to %d bytes listed its keys without error", fixture, i)
+			}
+		}
+
+		// so must corrupted record lengths
+		oMap, err := aliasFileTOC(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, offset := range oMap {
+			corrupt := append([]byte{}, data...)
+			binary.LittleEndian.PutUint32(corrupt[offset:], 0xfffffff0)
+			if _, err := AliasFromReader(bytes.NewReader(corrupt)); err == nil {
+				t.Errorf("%s with a corrupted %#x length decoded without error", fixture, key)
+			}
+		}
+		// and a corrupted number of TOC entries
+		d, err := newBookmarkDecoder(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := d.aliasHeader(); err != nil {
+			t.Fatal(err)
+		}
+		tocOffset := int(binary.LittleEndian.Uint32(data[d.headerSize:]) + d.headerSize)
+		corrupt := append([]byte{}, data...)
+		binary.LittleEndian.PutUint32(corrupt[tocOffset+16:], 0xfffffff0)
+		if _, err := AliasFromReader(bytes.NewReader(corrupt)); err == nil {
+			t.Errorf("%s with a corrupted TOC size decoded without error", fixture)
+		}
+		if _, err := BookmarkKeys(corrupt); err == nil {
+			t.Errorf("%s with a corrupted TOC size listed its keys without error", fixture)
+		}
+	}
+}
+
+func TestAliasFromReader_invalidHeader(t *testing.T) {
+	data, err := ioutil.ReadFile("fixtures/alias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		corrupt func(data []byte) []byte
+		wantErr string
+	}{
+		{name: "TOC offset past the end",
+			corrupt: func(data []byte) []byte {
+				binary.LittleEndian.PutUint32(data[56:], uint32(len(data)))
+				return data
+			},
+			wantErr: "TOC offset",
+		},
+		{name: "TOC header past the end",
+			corrupt: func(data []byte) []byte {
+				binary.LittleEndian.PutUint32(data[56:], uint32(len(data)-56-tocHeaderSize+1))
+				return data
+			},
+			wantErr: "TOC offset",
+		},
+		{name: "body size past the end",
+			corrupt: func(data []byte) []byte {
+				binary.LittleEndian.PutUint32(data[24:], uint32(len(data)))
+				return data
+			},
+			wantErr: "body size",
+		},
+		{name: "TOC size past the end",
+			corrupt: func(data []byte) []byte {
+				tocOffset := 56 + binary.LittleEndian.Uint32(data[56:])
+				binary.LittleEndian.PutUint32(data[tocOffset:], uint32(len(data)))
+				return data
+			},
+			wantErr: "TOC length",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			corrupt := tt.corrupt(append([]byte{}, data...))
+			_, err := AliasFromReader(bytes.NewReader(corrupt))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("AliasFromReader() error = %v, want an error about the %s", err, tt.wantErr)
+			}
+			if _, err := BookmarkKeys(corrupt); err == nil {
+				t.Error("BookmarkKeys() expected an error")
+			}
+		})
+	}
+}
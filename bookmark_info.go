@@ -0,0 +1,12 @@
+package cocoa
+
+import "time"
+
+// BookmarkInfo holds information about a bookmark/alias file itself as opposed
+// to the information about the target stored in its bookmark data.
+type BookmarkInfo struct {
+	// CreationDate is the file system creation time of the bookmark file.
+	CreationDate time.Time
+	// IsAlias reports if the Finder alias flag is set on the file.
+	IsAlias bool
+}
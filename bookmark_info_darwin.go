@@ -0,0 +1,37 @@
+package cocoa
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/mattetti/cocoa/darwin"
+)
+
+// BookmarkFileInfo returns information about the bookmark file found at the
+// passed path. The bookmark format doesn't record when a bookmark was created
+// (none of the known TOC keys hold that timestamp, KBookmarkFileCreationDate
+// is the creation date of the target) so the file system creation time of the
+// bookmark file is used instead.
+func BookmarkFileInfo(path string) (*BookmarkInfo, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the path of the bookmark - %s", err)
+	}
+
+	buf := make([]byte, 256)
+	attrs, err := darwin.GetAttrList(filepath.Clean(absPath),
+		darwin.AttrListMask{
+			CommonAttr: darwin.ATTR_CMN_OBJTYPE |
+				darwin.ATTR_CMN_CRTIME |
+				darwin.ATTR_CMN_FNDRINFO,
+		},
+		buf, darwin.FSOPT_NOFOLLOW)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the bookmark file attribute list - %s", err)
+	}
+
+	return &BookmarkInfo{
+		CreationDate: attrs.CreationTime.Time(),
+		IsAlias:      attrs.FileInfo.FinderFlags&darwin.FFKIsAlias > 0,
+	}, nil
+}
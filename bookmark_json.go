@@ -0,0 +1,138 @@
+package cocoa
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// jsonBookmarkData is the JSON representation of BookmarkData. Byte blobs are
+// base64 encoded by encoding/json, dates are RFC3339 formatted and zero dates
+// are null.
+type jsonBookmarkData struct {
+	FileSystemType         string            `json:"fileSystemType,omitempty"`
+	TargetPath             string            `json:"targetPath"`
+	Path                   []string          `json:"path"`
+	CNIDPath               []uint64          `json:"cnidPath"`
+	FileCreationDate       *time.Time        `json:"fileCreationDate"`
+	FileProperties         []byte            `json:"fileProperties"`
+	TypeData               []byte            `json:"typeData,omitempty"`
+	ContainingFolderIDX    uint32            `json:"containingFolderIndex"`
+	VolumePath             string            `json:"volumePath"`
+	VolumeIsRoot           bool              `json:"volumeIsRoot"`
+	VolumeURL              string            `json:"volumeURL"`
+	VolumeURLIsRelative    bool              `json:"volumeURLIsRelative,omitempty"`
+	URLLengths             []uint32          `json:"urlLengths,omitempty"`
+	VolumeMountPoint       string            `json:"volumeMountPoint,omitempty"`
+	VolumeName             string            `json:"volumeName"`
+	VolumeSize             int64             `json:"volumeSize"`
+	VolumeCreationDate     *time.Time        `json:"volumeCreationDate"`
+	VolumeUUID             string            `json:"volumeUUID"`
+	VolumeProperties       []byte            `json:"volumeProperties"`
+	CreationOptions        uint32            `json:"creationOptions"`
+	IsMinimal              bool              `json:"isMinimal,omitempty"`
+	WasFileReference       bool              `json:"wasFileReference"`
+	UserName               string            `json:"userName"`
+	CNID                   uint64            `json:"cnid"`
+	UID                    uint32            `json:"uid"`
+	Filename               string            `json:"filename,omitempty"`
+	SecurityExtension      []byte            `json:"securityExtension,omitempty"`
+	EmbeddedVolumeBookmark []byte            `json:"embeddedVolumeBookmark,omitempty"`
+	Unknown                map[uint32][]byte `json:"unknown,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. The fields needed to write the
+// bookmark back are serialized, the decoding details (Defaulted,
+// DecodeErrors and Extras) are left out. The target path is added for
+// convenience, it's derived from the other fields and ignored by
+// UnmarshalJSON.
+func (b *BookmarkData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonBookmarkData{
+		FileSystemType:         b.FileSystemType,
+		TargetPath:             b.TargetPath(),
+		Path:                   b.Path,
+		CNIDPath:               b.CNIDPath,
+		FileCreationDate:       jsonTime(b.FileCreationDate),
+		FileProperties:         b.FileProperties,
+		TypeData:               b.TypeData,
+		ContainingFolderIDX:    b.ContainingFolderIDX,
+		VolumePath:             b.VolumePath,
+		VolumeIsRoot:           b.VolumeIsRoot,
+		VolumeURL:              b.VolumeURL,
+		VolumeURLIsRelative:    b.VolumeURLIsRelative,
+		URLLengths:             b.URLLengths,
+		VolumeMountPoint:       b.VolumeMountPoint,
+		VolumeName:             b.VolumeName,
+		VolumeSize:             b.VolumeSize,
+		VolumeCreationDate:     jsonTime(b.VolumeCreationDate),
+		VolumeUUID:             b.VolumeUUID,
+		VolumeProperties:       b.VolumeProperties,
+		CreationOptions:        b.CreationOptions,
+		IsMinimal:              b.IsMinimal,
+		WasFileReference:       b.WasFileReference,
+		UserName:               b.UserName,
+		CNID:                   b.CNID,
+		UID:                    b.UID,
+		Filename:               b.Filename,
+		SecurityExtension:      b.SecurityExtension,
+		EmbeddedVolumeBookmark: b.EmbeddedVolumeBookmark,
+		Unknown:                b.Unknown,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler and replaces the content of b
+// with the bookmark serialized by MarshalJSON. b is left untouched on error.
+func (b *BookmarkData) UnmarshalJSON(data []byte) error {
+	var j jsonBookmarkData
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*b = BookmarkData{
+		FileSystemType:         j.FileSystemType,
+		Path:                   j.Path,
+		CNIDPath:               j.CNIDPath,
+		FileCreationDate:       timeFromJSON(j.FileCreationDate),
+		FileProperties:         j.FileProperties,
+		TypeData:               j.TypeData,
+		ContainingFolderIDX:    j.ContainingFolderIDX,
+		VolumePath:             j.VolumePath,
+		VolumeIsRoot:           j.VolumeIsRoot,
+		VolumeURL:              j.VolumeURL,
+		VolumeURLIsRelative:    j.VolumeURLIsRelative,
+		URLLengths:             j.URLLengths,
+		VolumeMountPoint:       j.VolumeMountPoint,
+		VolumeName:             j.VolumeName,
+		VolumeSize:             j.VolumeSize,
+		VolumeCreationDate:     timeFromJSON(j.VolumeCreationDate),
+		VolumeUUID:             j.VolumeUUID,
+		VolumeProperties:       j.VolumeProperties,
+		CreationOptions:        j.CreationOptions,
+		IsMinimal:              j.IsMinimal,
+		WasFileReference:       j.WasFileReference,
+		UserName:               j.UserName,
+		CNID:                   j.CNID,
+		UID:                    j.UID,
+		Filename:               j.Filename,
+		SecurityExtension:      j.SecurityExtension,
+		EmbeddedVolumeBookmark: j.EmbeddedVolumeBookmark,
+		Unknown:                j.Unknown,
+	}
+	return nil
+}
+
+// jsonTime returns the UTC time to serialize, nil for zero times so they are
+// serialized as null instead of year 1 dates.
+func jsonTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+// timeFromJSON returns the time deserialized by jsonTime.
+func timeFromJSON(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
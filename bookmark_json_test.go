@@ -0,0 +1,83 @@
+package cocoa
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBookmarkData_MarshalJSON(t *testing.T) {
+	var _ json.Marshaler = &BookmarkData{}
+	var _ json.Unmarshaler = &BookmarkData{}
+
+	f, err := os.Open("fixtures/alias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	b, err := AliasFromReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+	var fields struct {
+		TargetPath string `json:"targetPath"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields.TargetPath != b.TargetPath() {
+		t.Errorf("targetPath = %q, want %q", fields.TargetPath, b.TargetPath())
+	}
+	got := &BookmarkData{}
+	if err := json.Unmarshal(data, got); err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+	if !got.Equal(b) {
+		t.Errorf("the bookmark didn't round trip, got %v, want %v", got, b)
+	}
+	// the deserialized bookmark can be written back to a real alias
+	want, err := b.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	written, err := got.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(written, want) {
+		t.Error("the bookmark written from JSON doesn't match the original bookmark")
+	}
+
+	if err := got.UnmarshalJSON([]byte(`{"path": 42}`)); err == nil {
+		t.Error("expected an error unmarshaling invalid JSON")
+	}
+	if !got.Equal(b) {
+		t.Error("expected the bookmark to be left untouched on error")
+	}
+}
+
+func TestBookmarkData_MarshalJSON_zeroDates(t *testing.T) {
+	data, err := json.Marshal(&BookmarkData{Path: []string{"tmp"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{`"fileCreationDate":null`, `"volumeCreationDate":null`} {
+		if !strings.Contains(string(data), field) {
+			t.Errorf("MarshalJSON() = %s, expected %s", data, field)
+		}
+	}
+	got := &BookmarkData{}
+	if err := json.Unmarshal(data, got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.FileCreationDate.IsZero() || !got.VolumeCreationDate.IsZero() {
+		t.Errorf("expected zero dates, got %v and %v", got.FileCreationDate, got.VolumeCreationDate)
+	}
+}
@@ -0,0 +1,137 @@
+package cocoa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// BookmarkFormat identifies the format of alias/bookmark data and by
+// extension, the macOS era that produced it.
+type BookmarkFormat int
+
+const (
+	// BookmarkFormatUnknown is returned when the format couldn't be detected.
+	BookmarkFormatUnknown BookmarkFormat = iota
+	// BookmarkFormatAliasRecordV2 is a classic Mac OS alias record.
+	BookmarkFormatAliasRecordV2
+	// BookmarkFormatAliasRecordV3 is a Mac OS X alias record, superseded by
+	// bookmarks in 10.6.
+	BookmarkFormatAliasRecordV3
+	// BookmarkFormatBookmark is the bookmark data format introduced in 10.6.
+	BookmarkFormatBookmark
+	// BookmarkFormatAliasFile is bookmark data stored in an alias file (10.6+).
+	BookmarkFormatAliasFile
+	// BookmarkFormatSecurityScoped is bookmark data carrying a security
+	// extension, only produced by sandboxed apps since 10.7.3.
+	BookmarkFormatSecurityScoped
+)
+
+// bookmarkHeaderVersion is the value found after the bookmark magic number,
+// it might be a version number but it has been constant since 10.6.
+const bookmarkHeaderVersion = 0x10040000
+
+var errUnknownBookmarkFormat = errors.New("unknown bookmark format")
+
+func (f BookmarkFormat) String() string {
+	switch f {
+	case BookmarkFormatAliasRecordV2:
+		return "alias record v2 (classic Mac OS)"
+	case BookmarkFormatAliasRecordV3:
+		return "alias record v3 (Mac OS X before 10.6)"
+	case BookmarkFormatBookmark:
+		return "bookmark (macOS 10.6+)"
+	case BookmarkFormatAliasFile:
+		return "alias file (macOS 10.6+)"
+	case BookmarkFormatSecurityScoped:
+		return "security scoped bookmark (macOS 10.7.3+)"
+	default:
+		return "unknown"
+	}
+}
+
+// BookmarkVersion inspects the passed alias/bookmark data and returns its
+// format. The detection is best effort and relies on the following
+// heuristics:
+//
+//   - alias records start with a 4 byte app code followed by the big endian
+//     size of the record and the alias version (2 or 3).
+//   - bookmarks start with 'book', their size and the 0x10040000 version.
+//   - alias files start with 'book' 0x00000000 'mark' 0x00000000 followed by
+//     the header size and have the 0x10040000 version at offset 28.
+//   - bookmarks with a KBookmarkSecurityExtension entry were created by a
+//     sandboxed app using security scope which was added in 10.7.3.
+func BookmarkVersion(data []byte) (BookmarkFormat, error) {
+	if len(data) < 16 {
+		return BookmarkFormatUnknown, errUnknownBookmarkFormat
+	}
+
+	if bytes.HasPrefix(data, []byte("book")) {
+		if string(data[8:12]) == "mark" {
+			if len(data) < 32 || binary.LittleEndian.Uint32(data[28:]) != bookmarkHeaderVersion {
+				return BookmarkFormatUnknown, errUnknownBookmarkFormat
+			}
+			if aliasFileHasKey(data, KBookmarkSecurityExtension) {
+				return BookmarkFormatSecurityScoped, nil
+			}
+			return BookmarkFormatAliasFile, nil
+		}
+		if binary.LittleEndian.Uint32(data[8:]) == bookmarkHeaderVersion {
+			return BookmarkFormatBookmark, nil
+		}
+		return BookmarkFormatUnknown, errUnknownBookmarkFormat
+	}
+
+	// alias record
+	size := binary.BigEndian.Uint16(data[4:])
+	if int(size) <= len(data) {
+		switch binary.BigEndian.Uint16(data[6:]) {
+		case 2:
+			return BookmarkFormatAliasRecordV2, nil
+		case 3:
+			return BookmarkFormatAliasRecordV3, nil
+		}
+	}
+
+	return BookmarkFormatUnknown, errUnknownBookmarkFormat
+}
+
+// BookmarkKeys returns the sorted list of the TOC keys found in the passed
+// alias file data without decoding the entries.
+func BookmarkKeys(data []byte) ([]uint32, error) {
+	oMap, err := aliasFileTOC(data)
+	if err != nil {
+		return nil, err
+	}
+	return oMap.keys(), nil
+}
+
+// aliasFileHasKey returns positively if the TOC of the alias file data contains the
+// passed key.
+func aliasFileHasKey(data []byte, key uint32) bool {
+	oMap, err := aliasFileTOC(data)
+	if err != nil {
+		return false
+	}
+	_, ok := oMap[key]
+	return ok
+}
+
+// aliasFileTOC reads the header and TOC of the alias file data.
+func aliasFileTOC(data []byte) (offsetMap, error) {
+	d, err := newBookmarkDecoder(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if err := d.aliasHeader(); err != nil {
+		return nil, err
+	}
+	if err := d.seekTOC(); err != nil {
+		return nil, err
+	}
+	if err := d.toc(); err != nil {
+		return nil, fmt.Errorf("failed to read the TOC - %s", err)
+	}
+	return d.oMap, nil
+}
@@ -0,0 +1,91 @@
+package cocoa
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestBookmarkVersion(t *testing.T) {
+	bookmark := make([]byte, 48)
+	copy(bookmark, "book")
+	binary.LittleEndian.PutUint32(bookmark[4:], 48)
+	binary.LittleEndian.PutUint32(bookmark[8:], bookmarkHeaderVersion)
+	binary.LittleEndian.PutUint32(bookmark[12:], 48)
+
+	tests := []struct {
+		name    string
+		input   string
+		data    []byte
+		want    BookmarkFormat
+		wantErr bool
+	}{
+		{name: "alias file", input: "fixtures/alias", want: BookmarkFormatAliasFile},
+		{name: "exFAT alias file", input: "fixtures/exFATAlias", want: BookmarkFormatAliasFile},
+		{name: "alias record", input: "testExpectations/cocoa.hex", want: BookmarkFormatAliasRecordV2},
+		{name: "bookmark", data: bookmark, want: BookmarkFormatBookmark},
+		{name: "garbage", data: []byte("this is not a bookmark at all"), wantErr: true},
+		{name: "too short", data: []byte("book"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.data
+			if tt.input != "" {
+				var err error
+				if data, err = ioutil.ReadFile(tt.input); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := BookmarkVersion(data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BookmarkVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("BookmarkVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBookmarkKeys(t *testing.T) {
+	data, err := ioutil.ReadFile("fixtures/alias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := BookmarkKeys(data)
+	if err != nil {
+		t.Fatalf("BookmarkKeys() error = %v", err)
+	}
+	want := []uint32{
+		KBookmarkPath, KBookmarkCNIDPath, KBookmarkFileProperties, KBookmarkFileCreationDate,
+		KBookmarkUnknown, KBookmarkUnknown1, KBookmarkUnknown2,
+		KBookmarkVolumePath, KBookmarkVolumeURL, KBookmarkVolumeName, KBookmarkVolumeUUID,
+		KBookmarkVolumeSize, KBookmarkVolumeCreationDate, KBookmarkVolumeProperties, KBookmarkVolumeIsRoot,
+		KBookmarkContainingFolder, KBookmarkUserName, KBookmarkUID, KBookmarkWasFileReference,
+		KBookmarkCreationOptions, KBookmarkFullFileName, KBookmarkFileType,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BookmarkKeys() = %#x, want %#x", got, want)
+	}
+
+	if _, err := BookmarkKeys([]byte("not a bookmark")); err == nil {
+		t.Error("expected an error for invalid data")
+	}
+}
+
+func TestBookmarkKeyName(t *testing.T) {
+	tests := []struct {
+		key  uint32
+		want string
+	}{
+		{KBookmarkPath, "KBookmarkPath"},
+		{KBookmarkFileType, "KBookmarkFileType"},
+		{0xf081, "0xf081"},
+	}
+	for _, tt := range tests {
+		if got := BookmarkKeyName(tt.key); got != tt.want {
+			t.Errorf("BookmarkKeyName(%#x) = %s, want %s", tt.key, got, tt.want)
+		}
+	}
+}
@@ -1,24 +1,51 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/mattetti/cocoa"
 )
 
 var (
-	flagSrc   = flag.String("from", "", "Path of the file to link from")
-	flagDest  = flag.String("to", "", "Path of the file to link to")
-	flagParse = flag.String("parse", "", "debugging option")
-	flagDebug = flag.Bool("debug", false, "print more logs ")
+	flagSrc     = flag.String("from", "", "Path of the file to link from")
+	flagDest    = flag.String("to", "", "Path of the file to link to")
+	flagParse   = flag.String("parse", "", "debugging option")
+	flagJSON    = flag.Bool("json", false, "print the -parse result as indented JSON")
+	flagResolve = flag.String("resolve", "", "Path of an alias to print the current target of")
+	flagDebug   = flag.Bool("debug", false, "print more logs ")
+
+	flagConvertDir = flag.String("convert-dir", "", "Path of a directory in which all the symlinks are converted to aliases")
+	flagDryRun     = flag.Bool("dry-run", false, "only print what -convert-dir would do")
 )
 
 func main() {
 	flag.Parse()
 	if *flagParse != "" {
-		parse(*flagParse)
+		parse(*flagParse, *flagJSON)
+		return
+	}
+	if *flagResolve != "" {
+		if err := resolve(*flagResolve, os.Stdout); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *flagConvertDir != "" {
+		summary, err := convertDir(*flagConvertDir, *flagDryRun, os.Stdout)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("converted: %d, skipped: %d, failed: %d\n", summary.converted, summary.skipped, summary.failed)
+		if summary.failed > 0 {
+			os.Exit(1)
+		}
 		return
 	}
 	if *flagSrc == "" {
@@ -33,7 +60,12 @@ func main() {
 		cocoa.Debug = true
 	}
 
-	if cocoa.IsAlias(*flagSrc) {
+	isAlias, err := cocoa.IsAliasErr(*flagSrc)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if isAlias {
 		fmt.Println("let's not alias to an alias")
 		os.Exit(1)
 	}
@@ -42,7 +74,7 @@ func main() {
 	}
 }
 
-func parse(src string) {
+func parse(src string, asJSON bool) {
 	f, err := os.Open(src)
 	if err != nil {
 		panic(err)
@@ -50,6 +82,17 @@ func parse(src string) {
 	defer f.Close()
 
 	b, err := cocoa.AliasFromReader(f)
+	if asJSON {
+		if err != nil {
+			panic(err)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(b); err != nil {
+			panic(err)
+		}
+		return
+	}
 	fmt.Printf("%#v\n", b)
 	if err != nil {
 		panic(err)
@@ -58,3 +101,66 @@ func parse(src string) {
 		fmt.Printf("The lenght of the path (%d) doesn't match the length of the CNID path (%d)\n", len(b.Path), len(b.CNIDPath))
 	}
 }
+
+// resolve prints the current path of the target of the alias found at
+// aliasPath, flagged as stale if the target had to be looked up by CNID.
+func resolve(aliasPath string, out io.Writer) error {
+	f, err := os.Open(aliasPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	b, err := cocoa.AliasFromReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s - %s", aliasPath, err)
+	}
+	path, stale, err := b.Resolve()
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s - %s", aliasPath, err)
+	}
+	if stale {
+		fmt.Fprintln(out, path, "(stale)")
+		return nil
+	}
+	fmt.Fprintln(out, path)
+	return nil
+}
+
+type convertSummary struct {
+	converted int
+	skipped   int
+	failed    int
+}
+
+// convertDir walks root and converts every symlink to an alias. Symlinks
+// to missing targets are skipped.
+func convertDir(root string, dryRun bool, out io.Writer) (convertSummary, error) {
+	var summary convertSummary
+	err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.Mode()&os.ModeSymlink == 0 {
+			return nil
+		}
+		if _, err := os.Stat(path); err != nil {
+			fmt.Fprintf(out, "skipping %s - %s\n", path, err)
+			summary.skipped++
+			return nil
+		}
+		if dryRun {
+			fmt.Fprintf(out, "would convert %s\n", path)
+			summary.converted++
+			return nil
+		}
+		if err := cocoa.ConvertSymlink(path); err != nil {
+			fmt.Fprintf(out, "failed to convert %s - %s\n", path, err)
+			summary.failed++
+			return nil
+		}
+		fmt.Fprintf(out, "converted %s\n", path)
+		summary.converted++
+		return nil
+	})
+	return summary, err
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/mattetti/cocoa"
+)
+
+func Test_convertDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(dir, "target.txt")
+	if err := ioutil.WriteFile(target, []byte("cocoa"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "sub", "link")
+	if err := os.Symlink("../target.txt", link); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "dangling")); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := convertDir(dir, true, ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (convertSummary{converted: 1, skipped: 1}); summary != want {
+		t.Errorf("dry run summary = %+v, want %+v", summary, want)
+	}
+	if fi, err := os.Lstat(link); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Fatal("the dry run shouldn't touch the symlinks")
+	}
+
+	if runtime.GOOS != "darwin" {
+		return
+	}
+	summary, err = convertDir(dir, false, ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (convertSummary{converted: 1, skipped: 1}); summary != want {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+	if !cocoa.IsAlias(link) {
+		t.Errorf("expected %s to be converted to an alias", link)
+	}
+}
+
+func Test_resolve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := resolve(filepath.Join(dir, "missing"), ioutil.Discard); err == nil {
+		t.Error("expected an error resolving a missing alias")
+	}
+	if runtime.GOOS != "darwin" {
+		return
+	}
+
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(dir, "target.txt")
+	if err := ioutil.WriteFile(target, []byte("cocoa"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	alias := filepath.Join(dir, "alias")
+	if err := cocoa.Alias(target, alias); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := resolve(alias, &out); err != nil {
+		t.Fatalf("resolve() error = %v", err)
+	}
+	if want := target + "\n"; out.String() != want {
+		t.Errorf("resolve() printed %q, want %q", out.String(), want)
+	}
+
+	moved := filepath.Join(dir, "moved.txt")
+	if err := os.Rename(target, moved); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := resolve(alias, &out); err != nil {
+		t.Fatalf("resolve() error = %v", err)
+	}
+	if want := moved + " (stale)\n"; out.String() != want {
+		t.Errorf("resolve() printed %q, want %q", out.String(), want)
+	}
+}
@@ -4,8 +4,20 @@
 // of Gophers on Mac.
 package cocoa
 
+import (
+	"errors"
+	"fmt"
+)
+
 var (
 	Debug bool
+
+	// ErrNotDarwin is returned by the features only available on darwin when
+	// called on other platforms.
+	ErrNotDarwin = errors.New("cocoa: only implemented on darwin")
+	// ErrVolumeNotMounted is returned when resolving a bookmark whose target
+	// volume isn't mounted.
+	ErrVolumeNotMounted = errors.New("cocoa: volume not mounted")
 )
 
 // bookmarks flags
@@ -27,6 +39,9 @@ const (
 	bmk_st_zero = 0x0000
 	bmk_st_one  = 0x0001
 
+	bmk_string_st_utf8  = 0x0001
+	bmk_string_st_utf16 = 0x0002 // little endian
+
 	bmk_boolean_st_false = 0x0000
 	bmk_boolean_st_true  = 0x0001
 
@@ -60,15 +75,58 @@ const (
 	KBookmarkVolumeIsRoot       = 0x2030 // True if volume is FS root
 	KBookmarkVolumeBookmark     = 0x2040 // Embedded bookmark for disk image (TOC id)
 	KBookmarkVolumeMountPoint   = 0x2050 // A URL
-	//                           = 0x2070
-	KBookmarkContainingFolder  = 0xc001 // Index of containing folder in path
-	KBookmarkUserName          = 0xc011 // User that created bookmark
-	KBookmarkUID               = 0xc012 // UID that created bookmark
-	KBookmarkWasFileReference  = 0xd001 // True if the URL was a file reference
-	KBookmarkCreationOptions   = 0xd010
-	KBookmarkURLLengths        = 0xe003 // See below
-	KBookmarkFullFileName      = 0xf017
-	KBookmarkFileType          = 0xf022 // -> 0x201 looks like some file reference with file extension
-	KBookmarkSecurityExtension = 0xf080
+	KBookmarkVolumeUnknown      = 0x2070 // Seen on external volumes, type unknown (kept in BookmarkData.Unknown)
+	KBookmarkContainingFolder   = 0xc001 // Index of containing folder in path
+	KBookmarkUserName           = 0xc011 // User that created bookmark
+	KBookmarkUID                = 0xc012 // UID that created bookmark
+	KBookmarkWasFileReference   = 0xd001 // True if the URL was a file reference
+	KBookmarkCreationOptions    = 0xd010
+	KBookmarkURLLengths         = 0xe003 // See below
+	KBookmarkFullFileName       = 0xf017
+	KBookmarkFileType           = 0xf022 // -> 0x201 looks like some file reference with file extension
+	KBookmarkSecurityExtension  = 0xf080
 	//                           = 0xf081
 )
+
+// bookmarkKeyNames maps the known bookmark keys to their constant name.
+var bookmarkKeyNames = map[uint32]string{
+	KBookmarkPath:               "KBookmarkPath",
+	KBookmarkCNIDPath:           "KBookmarkCNIDPath",
+	KBookmarkFileProperties:     "KBookmarkFileProperties",
+	KBookmarkFileName:           "KBookmarkFileName",
+	KBookmarkFileID:             "KBookmarkFileID",
+	KBookmarkFileCreationDate:   "KBookmarkFileCreationDate",
+	KBookmarkUnknown:            "KBookmarkUnknown",
+	KBookmarkUnknown1:           "KBookmarkUnknown1",
+	KBookmarkUnknown2:           "KBookmarkUnknown2",
+	KBookmarkTOCPath:            "KBookmarkTOCPath",
+	KBookmarkVolumePath:         "KBookmarkVolumePath",
+	KBookmarkVolumeURL:          "KBookmarkVolumeURL",
+	KBookmarkVolumeName:         "KBookmarkVolumeName",
+	KBookmarkVolumeUUID:         "KBookmarkVolumeUUID",
+	KBookmarkVolumeSize:         "KBookmarkVolumeSize",
+	KBookmarkVolumeCreationDate: "KBookmarkVolumeCreationDate",
+	KBookmarkVolumeProperties:   "KBookmarkVolumeProperties",
+	KBookmarkVolumeIsRoot:       "KBookmarkVolumeIsRoot",
+	KBookmarkVolumeBookmark:     "KBookmarkVolumeBookmark",
+	KBookmarkVolumeMountPoint:   "KBookmarkVolumeMountPoint",
+	KBookmarkVolumeUnknown:      "KBookmarkVolumeUnknown",
+	KBookmarkContainingFolder:   "KBookmarkContainingFolder",
+	KBookmarkUserName:           "KBookmarkUserName",
+	KBookmarkUID:                "KBookmarkUID",
+	KBookmarkWasFileReference:   "KBookmarkWasFileReference",
+	KBookmarkCreationOptions:    "KBookmarkCreationOptions",
+	KBookmarkURLLengths:         "KBookmarkURLLengths",
+	KBookmarkFullFileName:       "KBookmarkFullFileName",
+	KBookmarkFileType:           "KBookmarkFileType",
+	KBookmarkSecurityExtension:  "KBookmarkSecurityExtension",
+}
+
+// BookmarkKeyName returns the name of the constant of the passed bookmark key,
+// for instance "KBookmarkPath" for 0x1004, or its hex value if it's unknown.
+func BookmarkKeyName(key uint32) string {
+	if name, ok := bookmarkKeyNames[key]; ok {
+		return name
+	}
+	return fmt.Sprintf("%#x", key)
+}
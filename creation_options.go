@@ -0,0 +1,85 @@
+package cocoa
+
+import "strings"
+
+// BookmarkCreationOptions are the NSURLBookmarkCreationOptions passed to Cocoa
+// when a bookmark is created. They are stored in the bookmark under the
+// KBookmarkCreationOptions key.
+// https://developer.apple.com/documentation/foundation/nsurl/bookmarkcreationoptions
+type BookmarkCreationOptions uint32
+
+const (
+	// BookmarkCreationPreferFileIDResolution is deprecated and ignored by
+	// recent macOS versions.
+	BookmarkCreationPreferFileIDResolution BookmarkCreationOptions = 1 << 8
+	// BookmarkCreationMinimalBookmark creates a bookmark with as little data
+	// as possible.
+	BookmarkCreationMinimalBookmark BookmarkCreationOptions = 1 << 9
+	// BookmarkCreationSuitableForBookmarkFile creates a bookmark that can be
+	// stored in an alias file.
+	BookmarkCreationSuitableForBookmarkFile BookmarkCreationOptions = 1 << 10
+	// BookmarkCreationWithSecurityScope creates a security scoped bookmark
+	// (sandboxed apps only).
+	BookmarkCreationWithSecurityScope BookmarkCreationOptions = 1 << 11
+	// BookmarkCreationSecurityScopeAllowOnlyReadAccess creates a security
+	// scoped bookmark only granting read access once resolved.
+	BookmarkCreationSecurityScopeAllowOnlyReadAccess BookmarkCreationOptions = 1 << 12
+	// BookmarkCreationWithoutImplicitSecurityScope disables the implicit
+	// security scope of the resolved URL.
+	BookmarkCreationWithoutImplicitSecurityScope BookmarkCreationOptions = 1 << 29
+)
+
+var bookmarkCreationOptionNames = []struct {
+	opt  BookmarkCreationOptions
+	name string
+}{
+	{BookmarkCreationPreferFileIDResolution, "PreferFileIDResolution"},
+	{BookmarkCreationMinimalBookmark, "MinimalBookmark"},
+	{BookmarkCreationSuitableForBookmarkFile, "SuitableForBookmarkFile"},
+	{BookmarkCreationWithSecurityScope, "WithSecurityScope"},
+	{BookmarkCreationSecurityScopeAllowOnlyReadAccess, "SecurityScopeAllowOnlyReadAccess"},
+	{BookmarkCreationWithoutImplicitSecurityScope, "WithoutImplicitSecurityScope"},
+}
+
+// Has returns positively if all the passed options are set.
+func (o BookmarkCreationOptions) Has(opts BookmarkCreationOptions) bool {
+	return o&opts == opts
+}
+
+// IsMinimal returns positively if the bookmark was created as a minimal bookmark.
+func (o BookmarkCreationOptions) IsMinimal() bool {
+	return o.Has(BookmarkCreationMinimalBookmark)
+}
+
+// IsSuitableForBookmarkFile returns positively if the bookmark was created to
+// be stored in an alias file.
+func (o BookmarkCreationOptions) IsSuitableForBookmarkFile() bool {
+	return o.Has(BookmarkCreationSuitableForBookmarkFile)
+}
+
+// IsSecurityScoped returns positively if the bookmark is security scoped.
+func (o BookmarkCreationOptions) IsSecurityScoped() bool {
+	return o.Has(BookmarkCreationWithSecurityScope)
+}
+
+// IsReadOnlySecurityScope returns positively if the security scope of the
+// bookmark only grants read access.
+func (o BookmarkCreationOptions) IsReadOnlySecurityScope() bool {
+	return o.Has(BookmarkCreationSecurityScopeAllowOnlyReadAccess)
+}
+
+// String returns the names of the options that are set, separated by a pipe.
+func (o BookmarkCreationOptions) String() string {
+	names := []string{}
+	for _, n := range bookmarkCreationOptionNames {
+		if o.Has(n.opt) {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// CreationOptionFlags returns the creation options of the bookmark.
+func (b *BookmarkData) CreationOptionFlags() BookmarkCreationOptions {
+	return BookmarkCreationOptions(b.CreationOptions)
+}
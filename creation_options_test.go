@@ -0,0 +1,42 @@
+package cocoa
+
+import "testing"
+
+func TestBookmarkData_CreationOptionFlags(t *testing.T) {
+	tests := []struct {
+		name            string
+		creationOptions uint32
+		want            BookmarkCreationOptions
+		wantString      string
+	}{
+		{name: "alias", creationOptions: 512,
+			want: BookmarkCreationMinimalBookmark, wantString: "MinimalBookmark"},
+		{name: "bookmark file", creationOptions: 1024,
+			want: BookmarkCreationSuitableForBookmarkFile, wantString: "SuitableForBookmarkFile"},
+		{name: "security scoped", creationOptions: 0x1800,
+			want:       BookmarkCreationWithSecurityScope | BookmarkCreationSecurityScopeAllowOnlyReadAccess,
+			wantString: "WithSecurityScope|SecurityScopeAllowOnlyReadAccess"},
+		{name: "none", creationOptions: 0, want: 0, wantString: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BookmarkData{CreationOptions: tt.creationOptions}
+			got := b.CreationOptionFlags()
+			if got != tt.want {
+				t.Errorf("BookmarkData.CreationOptionFlags() = %#x, want %#x", got, tt.want)
+			}
+			if got.String() != tt.wantString {
+				t.Errorf("BookmarkData.CreationOptionFlags().String() = %q, want %q", got, tt.wantString)
+			}
+			if got.IsMinimal() != (tt.creationOptions == 512) {
+				t.Errorf("BookmarkData.CreationOptionFlags().IsMinimal() = %v", got.IsMinimal())
+			}
+			if got.IsSuitableForBookmarkFile() != (tt.creationOptions == 1024) {
+				t.Errorf("BookmarkData.CreationOptionFlags().IsSuitableForBookmarkFile() = %v", got.IsSuitableForBookmarkFile())
+			}
+			if got.IsSecurityScoped() != (tt.creationOptions == 0x1800) {
+				t.Errorf("BookmarkData.CreationOptionFlags().IsSecurityScoped() = %v", got.IsSecurityScoped())
+			}
+		})
+	}
+}
@@ -1,23 +1,113 @@
 package darwin
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	"path/filepath"
+	"syscall"
 )
 
 // SetAsAlias flags the destination file as an alias.
 // This function doesn't verify that the file is actually an alias.
 // Don't use on the wrong file!
+// The existing finder info is preserved, the 'alis' type and 'MACS' creator
+// codes are only set if the file doesn't have any.
 func SetAsAlias(path string) error {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return fmt.Errorf("%s can't be converted to an absolute path - %s", path, err)
 	}
-	aliasMagicFlag := []byte{0x61, 0x6c, 0x69, 0x73, 0x4d, 0x41, 0x43, 0x53, 0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
-	var dataval *byte
-	datalen := len(aliasMagicFlag)
-	if datalen > 0 {
-		dataval = &aliasMagicFlag[0]
+	absPath = filepath.Clean(absPath)
+	info, err := finderInfo(absPath)
+	if err != nil {
+		return err
+	}
+	if binary.BigEndian.Uint32(info[0:]) == 0 && binary.BigEndian.Uint32(info[4:]) == 0 {
+		binary.BigEndian.PutUint32(info[0:], KAliasFileType)
+		binary.BigEndian.PutUint32(info[4:], KSystemCreator)
+	}
+	flags := binary.BigEndian.Uint16(info[8:])
+	return writeFinderFlags(absPath, info, flags|FFKIsAlias)
+}
+
+// UnsetAlias clears the alias flag of the file, the rest of the finder info
+// is preserved. The finder info is removed if it's then empty.
+func UnsetAlias(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("%s can't be converted to an absolute path - %s", path, err)
+	}
+	absPath = filepath.Clean(absPath)
+	info, err := finderInfo(absPath)
+	if err != nil {
+		return err
+	}
+	flags := binary.BigEndian.Uint16(info[8:])
+	return writeFinderFlags(absPath, info, flags&^FFKIsAlias)
+}
+
+// GetFinderFlags returns the Finder flags (FFK*) of the file, 0 if the file
+// doesn't have any finder info.
+func GetFinderFlags(path string) (uint16, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return 0, fmt.Errorf("%s can't be converted to an absolute path - %s", path, err)
+	}
+	info, err := finderInfo(filepath.Clean(absPath))
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(info[8:]), nil
+}
+
+// SetFinderFlags replaces the Finder flags (FFK*) of the file, the rest of
+// the finder info is preserved. Use GetFinderFlags to toggle a single flag.
+// The finder info is removed if it's then empty.
+func SetFinderFlags(path string, flags uint16) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("%s can't be converted to an absolute path - %s", path, err)
+	}
+	absPath = filepath.Clean(absPath)
+	info, err := finderInfo(absPath)
+	if err != nil {
+		return err
+	}
+	return writeFinderFlags(absPath, info, flags)
+}
+
+// writeFinderFlags stores the flags in the passed finder info and writes it
+// to the file. The finder info is removed if it's then empty.
+func writeFinderFlags(path string, info []byte, flags uint16) error {
+	binary.BigEndian.PutUint16(info[8:], flags)
+	if bytes.Equal(info, make([]byte, len(info))) {
+		if err := Removexattr(path, "com.apple.FinderInfo"); err != nil && err != syscall.ENOATTR {
+			return err
+		}
+		return nil
+	}
+	return setxattr(path, "com.apple.FinderInfo", &info[0], len(info), 0, 0)
+}
+
+// finderInfo returns the 32 bytes of finder info of the file, zeroed if the
+// file doesn't have any.
+func finderInfo(path string) ([]byte, error) {
+	info := make([]byte, 32)
+	_, err := GetxattrBuf(path, "com.apple.FinderInfo", info, 0)
+	if err != nil && err != syscall.ENOATTR {
+		return nil, fmt.Errorf("failed to read the finder info of %s - %s", path, err)
+	}
+	return info, nil
+}
+
+// SetFinderInfo writes the passed file info in the com.apple.FinderInfo
+// extended attribute of the file, replacing the existing value.
+func SetFinderInfo(path string, info FileInfo) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("%s can't be converted to an absolute path - %s", path, err)
 	}
-	return setxattr(filepath.Clean(absPath), "com.apple.FinderInfo", dataval, datalen, 0, 0)
+	data := EncodeFinderInfo(info)
+	return setxattr(filepath.Clean(absPath), "com.apple.FinderInfo", &data[0], len(data), 0, 0)
 }
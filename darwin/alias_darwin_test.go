@@ -0,0 +1,148 @@
+package darwin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestSetAsAlias_UnsetAlias(t *testing.T) {
+	f, err := ioutil.TempFile("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	// existing finder info with a label color and custom codes
+	info := FileInfo{
+		FileType:    0x54455854, // 'TEXT'
+		FileCreator: 0x74747874, // 'ttxt'
+		FinderFlags: FFKColor,
+	}
+	if err := SetFinderInfo(f.Name(), info); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetAsAlias(f.Name()); err != nil {
+		t.Fatalf("SetAsAlias() error = %v", err)
+	}
+	got, err := Getxattr(f.Name(), "com.apple.FinderInfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := EncodeFinderInfo(info)
+	binary.BigEndian.PutUint16(want[8:], FFKColor|FFKIsAlias)
+	if !bytes.Equal(got, want) {
+		t.Errorf("SetAsAlias() finder info = %x, want %x", got, want)
+	}
+
+	if err := UnsetAlias(f.Name()); err != nil {
+		t.Fatalf("UnsetAlias() error = %v", err)
+	}
+	got, err = Getxattr(f.Name(), "com.apple.FinderInfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := EncodeFinderInfo(info); !bytes.Equal(got, want) {
+		t.Errorf("UnsetAlias() finder info = %x, want %x", got, want)
+	}
+
+	// finder info only holding the alias flag is removed
+	if err := Removexattr(f.Name(), "com.apple.FinderInfo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetAsAlias(f.Name()); err != nil {
+		t.Fatalf("SetAsAlias() error = %v", err)
+	}
+	got, err = Getxattr(f.Name(), "com.apple.FinderInfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got[:8]) != "alisMACS" {
+		t.Errorf("SetAsAlias() type and creator = %q, want alisMACS", got[:8])
+	}
+	if err := SetFinderInfo(f.Name(), FileInfo{FinderFlags: FFKIsAlias}); err != nil {
+		t.Fatal(err)
+	}
+	if err := UnsetAlias(f.Name()); err != nil {
+		t.Fatalf("UnsetAlias() error = %v", err)
+	}
+	if _, err := Getxattr(f.Name(), "com.apple.FinderInfo"); err != syscall.ENOATTR {
+		t.Errorf("expected the empty finder info to be removed, got %v", err)
+	}
+	// nothing to unset
+	if err := UnsetAlias(f.Name()); err != nil {
+		t.Errorf("UnsetAlias() error = %v", err)
+	}
+}
+
+func TestSetAsAlias_extendedFinderInfo(t *testing.T) {
+	f, err := ioutil.TempFile("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	// only a type code and the extended finder info (put away folder ID)
+	info := make([]byte, 32)
+	copy(info, "TEXT")
+	binary.BigEndian.PutUint32(info[28:], 0x1234)
+	if err := setxattr(f.Name(), "com.apple.FinderInfo", &info[0], len(info), 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetAsAlias(f.Name()); err != nil {
+		t.Fatalf("SetAsAlias() error = %v", err)
+	}
+	got, err := Getxattr(f.Name(), "com.apple.FinderInfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append([]byte(nil), info...)
+	binary.BigEndian.PutUint16(want[8:], FFKIsAlias)
+	if !bytes.Equal(got, want) {
+		t.Errorf("SetAsAlias() finder info = %x, want %x", got, want)
+	}
+}
+
+func TestSetFinderFlags(t *testing.T) {
+	f, err := ioutil.TempFile("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	// no finder info yet
+	if flags, err := GetFinderFlags(f.Name()); err != nil || flags != 0 {
+		t.Errorf("GetFinderFlags() = %#x, %v, want 0, nil", flags, err)
+	}
+
+	info := FileInfo{FileType: 0x54455854} // 'TEXT'
+	if err := SetFinderInfo(f.Name(), info); err != nil {
+		t.Fatal(err)
+	}
+	// red label
+	flags := FFKIsInvisible | FFKHasCustomIcon | 6<<1
+	if err := SetFinderFlags(f.Name(), flags); err != nil {
+		t.Fatalf("SetFinderFlags() error = %v", err)
+	}
+	got, err := GetFinderFlags(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != flags {
+		t.Errorf("GetFinderFlags() = %#x, want %#x", got, flags)
+	}
+	data, err := Getxattr(f.Name(), "com.apple.FinderInfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info.FinderFlags = flags
+	if want := EncodeFinderInfo(info); !bytes.Equal(data, want) {
+		t.Errorf("SetFinderFlags() finder info = %x, want %x", data, want)
+	}
+}
@@ -0,0 +1,146 @@
+package darwin
+
+// Sizes (in bytes) of the fixed part of each attribute returned by
+// getattrlist(), as documented in the man page and <sys/attr.h>.
+// Attributes are packed in the order of their bits within each group (common,
+// volume, directory, file, fork), with ATTR_CMN_RETURNED_ATTRS always first.
+// Variable length attributes (names, paths...) are stored as an attrreference
+// (8 bytes) pointing to the data packed after the fixed part.
+// All the sizes are multiples of 4 which keeps every attribute 4 byte aligned.
+//
+// Any requested attribute the decoder doesn't handle must be skipped using
+// its size here, otherwise all following attributes would be read from the
+// wrong offset.
+var (
+	commonAttrSizes = map[uint32]int64{
+		ATTR_CMN_RETURNED_ATTRS:    20, // attribute_set_t
+		ATTR_CMN_NAME:              8,  // attrreference_t
+		ATTR_CMN_DEVID:             4,  // dev_t
+		ATTR_CMN_FSID:              8,  // fsid_t
+		ATTR_CMN_OBJTYPE:           4,  // fsobj_type_t
+		ATTR_CMN_OBJTAG:            4,  // fsobj_tag_t
+		ATTR_CMN_OBJID:             8,  // fsobj_id_t
+		ATTR_CMN_OBJPERMANENTID:    8,  // fsobj_id_t
+		ATTR_CMN_PAROBJID:          8,  // fsobj_id_t
+		ATTR_CMN_SCRIPT:            4,  // text_encoding_t
+		ATTR_CMN_CRTIME:            16, // struct timespec
+		ATTR_CMN_MODTIME:           16, // struct timespec
+		ATTR_CMN_CHGTIME:           16, // struct timespec
+		ATTR_CMN_ACCTIME:           16, // struct timespec
+		ATTR_CMN_BKUPTIME:          16, // struct timespec
+		ATTR_CMN_FNDRINFO:          32, // u_int8_t[32]
+		ATTR_CMN_OWNERID:           4,  // uid_t
+		ATTR_CMN_GRPID:             4,  // gid_t
+		ATTR_CMN_ACCESSMASK:        4,  // u_int32_t
+		ATTR_CMN_FLAGS:             4,  // u_int32_t
+		ATTR_CMN_NAMEDATTRCOUNT:    4,  // u_int32_t ATTR_CMN_GEN_COUNT, only with FSOPT_ATTR_CMN_EXTENDED
+		ATTR_CMN_NAMEDATTRLIST:     4,  // u_int32_t ATTR_CMN_DOCUMENT_ID, only with FSOPT_ATTR_CMN_EXTENDED
+		ATTR_CMN_USERACCESS:        4,  // u_int32_t
+		ATTR_CMN_EXTENDED_SECURITY: 8,  // attrreference_t
+		ATTR_CMN_UUID:              16, // guid_t
+		ATTR_CMN_GRPUUID:           16, // guid_t
+		ATTR_CMN_FILEID:            8,  // u_int64_t
+		ATTR_CMN_PARENTID:          8,  // u_int64_t
+		ATTR_CMN_FULLPATH:          8,  // attrreference_t
+		ATTR_CMN_ADDEDTIME:         16, // struct timespec
+	}
+
+	volAttrSizes = map[uint32]int64{
+		ATTR_VOL_FSTYPE:          4,  // u_int32_t
+		ATTR_VOL_SIGNATURE:       4,  // u_int32_t
+		ATTR_VOL_SIZE:            8,  // off_t
+		ATTR_VOL_SPACEFREE:       8,  // off_t
+		ATTR_VOL_SPACEAVAIL:      8,  // off_t
+		ATTR_VOL_MINALLOCATION:   8,  // off_t
+		ATTR_VOL_ALLOCATIONCLUMP: 8,  // off_t
+		ATTR_VOL_IOBLOCKSIZE:     4,  // u_int32_t
+		ATTR_VOL_OBJCOUNT:        4,  // u_int32_t
+		ATTR_VOL_FILECOUNT:       4,  // u_int32_t
+		ATTR_VOL_DIRCOUNT:        4,  // u_int32_t
+		ATTR_VOL_MAXOBJCOUNT:     4,  // u_int32_t
+		ATTR_VOL_MOUNTPOINT:      8,  // attrreference_t
+		ATTR_VOL_NAME:            8,  // attrreference_t
+		ATTR_VOL_MOUNTFLAGS:      4,  // u_int32_t
+		ATTR_VOL_MOUNTEDDEVICE:   8,  // attrreference_t
+		ATTR_VOL_ENCODINGSUSED:   8,  // unsigned long long
+		ATTR_VOL_CAPABILITIES:    32, // vol_capabilities_attr_t
+		ATTR_VOL_UUID:            16, // uuid_t
+		ATTR_VOL_ATTRIBUTES:      40, // vol_attributes_attr_t
+	}
+
+	dirAttrSizes = map[uint32]int64{
+		ATTR_DIR_LINKCOUNT:   4, // u_int32_t
+		ATTR_DIR_ENTRYCOUNT:  4, // u_int32_t
+		ATTR_DIR_MOUNTSTATUS: 4, // u_int32_t
+	}
+
+	fileAttrSizes = map[uint32]int64{
+		ATTR_FILE_LINKCOUNT:     4,  // u_int32_t
+		ATTR_FILE_TOTALSIZE:     8,  // off_t
+		ATTR_FILE_ALLOCSIZE:     8,  // off_t
+		ATTR_FILE_IOBLOCKSIZE:   4,  // u_int32_t
+		ATTR_FILE_CLUMPSIZE:     4,  // u_int32_t
+		ATTR_FILE_DEVTYPE:       4,  // u_int32_t
+		ATTR_FILE_FILETYPE:      4,  // u_int32_t
+		ATTR_FILE_FORKCOUNT:     4,  // u_int32_t
+		ATTR_FILE_FORKLIST:      8,  // attrreference_t
+		ATTR_FILE_DATALENGTH:    8,  // off_t
+		ATTR_FILE_DATAALLOCSIZE: 8,  // off_t
+		ATTR_FILE_DATAEXTENTS:   64, // extentrecord
+		ATTR_FILE_RSRCLENGTH:    8,  // off_t
+		ATTR_FILE_RSRCALLOCSIZE: 8,  // off_t
+		ATTR_FILE_RSRCEXTENTS:   64, // extentrecord
+	}
+
+	forkAttrSizes = map[uint32]int64{
+		ATTR_FORK_TOTALSIZE: 8, // off_t
+		ATTR_FORK_ALLOCSIZE: 8, // off_t
+	}
+)
+
+// Maximum sizes of the data referenced by the variable length attributes.
+const (
+	// NAME_MAX UTF-16 units encoded as UTF-8 plus the null terminator.
+	maxNameDataSize = 255*3 + 1
+	// PATH_MAX, including the null terminator.
+	maxPathDataSize = 1024
+	// struct kauth_filesec header followed by KAUTH_ACL_MAX_ENTRIES (128)
+	// access control entries of 24 bytes.
+	maxFilesecDataSize = 44 + 128*24
+)
+
+var (
+	commonAttrDataSizes = map[uint32]int64{
+		ATTR_CMN_NAME:              maxNameDataSize,
+		ATTR_CMN_EXTENDED_SECURITY: maxFilesecDataSize,
+		ATTR_CMN_FULLPATH:          maxPathDataSize,
+	}
+
+	volAttrDataSizes = map[uint32]int64{
+		ATTR_VOL_MOUNTPOINT:    maxPathDataSize,
+		ATTR_VOL_NAME:          maxNameDataSize,
+		ATTR_VOL_MOUNTEDDEVICE: maxPathDataSize,
+	}
+)
+
+// AttrBufSize returns the size of the attribute buffer GetAttrList needs to
+// return all the attributes of the mask, including the longest names and
+// paths the attributes can reference.
+func AttrBufSize(mask AttrListMask) int {
+	// length prefix
+	size := int64(4)
+	add := func(attrs uint32, sizes, dataSizes map[uint32]int64) {
+		for bit, s := range sizes {
+			if attrs&bit > 0 {
+				// data is 4 byte aligned
+				size += s + (dataSizes[bit]+3)&^3
+			}
+		}
+	}
+	add(mask.CommonAttr, commonAttrSizes, commonAttrDataSizes)
+	add(mask.VolAttr, volAttrSizes, volAttrDataSizes)
+	add(mask.DirAttr, dirAttrSizes, nil)
+	add(mask.FileAttr, fileAttrSizes, nil)
+	add(mask.ForkAttr, forkAttrSizes, nil)
+	return int(size)
+}
@@ -0,0 +1,58 @@
+package darwin
+
+import "testing"
+
+func TestAttrSizes(t *testing.T) {
+	tests := []struct {
+		name  string
+		all   uint32
+		sizes map[uint32]int64
+	}{
+		{"common", ATTR_CMN_ALL_ATTRS, commonAttrSizes},
+		{"volume", ATTR_VOL_ALL_ATTRS &^ ATTR_VOL_INFO, volAttrSizes},
+		{"directory", ATTR_DIR_ALL_ATTRS, dirAttrSizes},
+		{"file", ATTR_FILE_ALL_ATTRS, fileAttrSizes},
+		{"fork", ATTR_FORK_ALL_ATTRS, forkAttrSizes},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for bit := uint32(1); bit != 0; bit <<= 1 {
+				if tt.all&bit == 0 {
+					continue
+				}
+				if _, ok := tt.sizes[bit]; !ok {
+					t.Errorf("missing size for attribute %#x", bit)
+				}
+			}
+			for bit, size := range tt.sizes {
+				if size <= 0 || size%4 != 0 {
+					t.Errorf("attribute %#x has an unaligned size %d", bit, size)
+				}
+			}
+		})
+	}
+}
+
+func TestAttrBufSize(t *testing.T) {
+	tests := []struct {
+		name string
+		mask AttrListMask
+		want int
+	}{
+		{"file id", AttrListMask{CommonAttr: ATTR_CMN_FILEID}, 4 + 8},
+		{"name", AttrListMask{CommonAttr: ATTR_CMN_NAME}, 4 + 8 + 768},
+		{"volume",
+			AttrListMask{
+				CommonAttr: ATTR_CMN_CRTIME,
+				VolAttr:    ATTR_VOL_SIZE | ATTR_VOL_NAME | ATTR_VOL_MOUNTFLAGS | ATTR_VOL_UUID,
+			},
+			4 + 16 + 8 + 8 + 768 + 4 + 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AttrBufSize(tt.mask); got != tt.want {
+				t.Errorf("AttrBufSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
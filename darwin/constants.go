@@ -196,6 +196,87 @@ const (
 	ATTR_FILE_FILETYPE      uint32 = 0x00000040
 )
 
+// volume mount flags
+// from sys/mount.h
+const (
+	MNT_RDONLY      uint32 = 0x00000001 /* read only filesystem */
+	MNT_NOSUID      uint32 = 0x00000008 /* don't honor setuid bits on fs */
+	MNT_NODEV       uint32 = 0x00000010 /* don't interpret special files */
+	MNT_LOCAL       uint32 = 0x00001000 /* filesystem is stored locally */
+	MNT_QUARANTINE  uint32 = 0x00000400 /* file system is quarantined */
+	MNT_DONTBROWSE  uint32 = 0x00100000 /* file system is not appropriate path to user data */
+	MNT_AUTOMOUNTED uint32 = 0x00400000 /* filesystem was mounted by automounter */
+	MNT_JOURNALED   uint32 = 0x00800000 /* filesystem is journaled */
+)
+
+// file flags, returned by ATTR_CMN_FLAGS
+// from sys/stat.h
+const (
+	UF_NODUMP     uint32 = 0x00000001 /* do not dump file */
+	UF_IMMUTABLE  uint32 = 0x00000002 /* file may not be changed */
+	UF_APPEND     uint32 = 0x00000004 /* writes to file may only append */
+	UF_OPAQUE     uint32 = 0x00000008 /* directory is opaque wrt. union */
+	UF_COMPRESSED uint32 = 0x00000020 /* file is compressed */
+	UF_TRACKED    uint32 = 0x00000040 /* renames and deletes are tracked */
+	UF_DATAVAULT  uint32 = 0x00000080 /* entitlement required for reading and writing */
+	UF_HIDDEN     uint32 = 0x00008000 /* hint that this item should not be displayed in a GUI */
+	SF_ARCHIVED   uint32 = 0x00010000 /* file is archived */
+	SF_IMMUTABLE  uint32 = 0x00020000 /* file may not be changed */
+	SF_APPEND     uint32 = 0x00040000 /* writes to file may only append */
+)
+
+// volume capabilities, returned by ATTR_VOL_CAPABILITIES
+// from sys/attr.h
+const (
+	// indexes of the vol_capabilities_set_t words
+	VOL_CAPABILITIES_FORMAT     = 0
+	VOL_CAPABILITIES_INTERFACES = 1
+
+	VOL_CAP_FMT_PERSISTENTOBJECTIDS    uint32 = 0x00000001
+	VOL_CAP_FMT_SYMBOLICLINKS          uint32 = 0x00000002
+	VOL_CAP_FMT_HARDLINKS              uint32 = 0x00000004
+	VOL_CAP_FMT_JOURNAL                uint32 = 0x00000008
+	VOL_CAP_FMT_JOURNAL_ACTIVE         uint32 = 0x00000010
+	VOL_CAP_FMT_NO_ROOT_TIMES          uint32 = 0x00000020
+	VOL_CAP_FMT_SPARSE_FILES           uint32 = 0x00000040
+	VOL_CAP_FMT_ZERO_RUNS              uint32 = 0x00000080
+	VOL_CAP_FMT_CASE_SENSITIVE         uint32 = 0x00000100
+	VOL_CAP_FMT_CASE_PRESERVING        uint32 = 0x00000200
+	VOL_CAP_FMT_FAST_STATFS            uint32 = 0x00000400
+	VOL_CAP_FMT_2TB_FILESIZE           uint32 = 0x00000800
+	VOL_CAP_FMT_OPENDENYMODES          uint32 = 0x00001000
+	VOL_CAP_FMT_HIDDEN_FILES           uint32 = 0x00002000
+	VOL_CAP_FMT_PATH_FROM_ID           uint32 = 0x00004000
+	VOL_CAP_FMT_NO_VOLUME_SIZES        uint32 = 0x00008000
+	VOL_CAP_FMT_DECMPFS_COMPRESSION    uint32 = 0x00010000
+	VOL_CAP_FMT_64BIT_OBJECT_IDS       uint32 = 0x00020000
+	VOL_CAP_FMT_DIR_HARDLINKS          uint32 = 0x00040000
+	VOL_CAP_FMT_DOCUMENT_ID            uint32 = 0x00080000
+	VOL_CAP_FMT_WRITE_GENERATION_COUNT uint32 = 0x00100000
+	VOL_CAP_FMT_NO_IMMUTABLE_FILES     uint32 = 0x00200000
+	VOL_CAP_FMT_NO_PERMISSIONS         uint32 = 0x00400000
+
+	VOL_CAP_INT_SEARCHFS          uint32 = 0x00000001
+	VOL_CAP_INT_ATTRLIST          uint32 = 0x00000002
+	VOL_CAP_INT_NFSEXPORT         uint32 = 0x00000004
+	VOL_CAP_INT_READDIRATTR       uint32 = 0x00000008
+	VOL_CAP_INT_EXCHANGEDATA      uint32 = 0x00000010
+	VOL_CAP_INT_COPYFILE          uint32 = 0x00000020
+	VOL_CAP_INT_ALLOCATE          uint32 = 0x00000040
+	VOL_CAP_INT_VOL_RENAME        uint32 = 0x00000080
+	VOL_CAP_INT_ADVLOCK           uint32 = 0x00000100
+	VOL_CAP_INT_FLOCK             uint32 = 0x00000200
+	VOL_CAP_INT_EXTENDED_SECURITY uint32 = 0x00000400
+	VOL_CAP_INT_USERACCESS        uint32 = 0x00000800
+	VOL_CAP_INT_MANLOCK           uint32 = 0x00001000
+	VOL_CAP_INT_NAMEDSTREAMS      uint32 = 0x00002000
+	VOL_CAP_INT_EXTENDED_ATTR     uint32 = 0x00004000
+	VOL_CAP_INT_CLONE             uint32 = 0x00010000
+	VOL_CAP_INT_SNAPSHOT          uint32 = 0x00020000
+	VOL_CAP_INT_RENAME_SWAP       uint32 = 0x00040000
+	VOL_CAP_INT_RENAME_EXCL       uint32 = 0x00080000
+)
+
 const (
 	// from sys/vnode.h
 	VNON uint32 = iota
@@ -211,30 +292,50 @@ const (
 	VCPLX
 )
 
-// finder flags
+// Finder flags, stored big endian in bytes 8-9 of the com.apple.FinderInfo
+// extended attribute, see FileInfo.FinderFlags, GetFinderFlags and
+// SetFinderFlags. Bits 0x0020 and 0x0200 are reserved.
 // https://opensource.apple.com/source/CarbonHeaders/CarbonHeaders-9A581/Finder.h
 const (
-	FFKIsOnDesk = 0x0001 /* Files and folders (System 6) */
-	FFKColor    = 0x000E /* Files and folders */
-	/* bit 0x0020 was kRequireSwitchLaunch, but is now reserved for future use*/
-	FFKIsShared = 0x0040 /* Files only (Applications only) */
-	/* If clear, the application needs to write to */
-	/* its resource fork, and therefore cannot be */
-	/* shared on a server */
-	FFKHasNoINITs = 0x0080 /* Files only (Extensions/Control Panels only) */
-	/* This file contains no INIT resource */
-	FFKHasBeenInited = 0x0100 /* Files only */
-	/* Clear if the file contains desktop database */
-	/* resources ('BNDL', 'FREF', 'open', 'kind'...) */
-	/* that have not been added yet. Set only by the Finder */
-	/* Reserved for folders - make sure this bit is cleared for folders */
-	/* bit 0x0200 was the letter bit for AOCE, but is now reserved for future use */
-	FFKHasCustomIcon = 0x0400 /* Files and folders */
-	FFKIsStationery  = 0x0800 /* Files only */
-	FFKNameLocked    = 0x1000 /* Files and folders */
-	FFKHasBundle     = 0x2000 /* Files and folders */
-	/* Indicates that a file has a BNDL resource */
-	/* Indicates that a folder is displayed as a package */
-	FFKIsInvisible = 0x4000 /* Files and folders */
-	FFKIsAlias     = 0x8000 /* Files only */
+	// FFKIsOnDesk is only used by System 6 (files and folders).
+	FFKIsOnDesk uint16 = 0x0001
+	// FFKColor masks the 3 bits of the label color index (1 to 7), shift the
+	// index left by 1 to set it: flags&^FFKColor | index<<1
+	FFKColor uint16 = 0x000E
+	// FFKIsShared is cleared if the application needs to write to its
+	// resource fork and therefore can't be shared on a server (applications
+	// only).
+	FFKIsShared uint16 = 0x0040
+	// FFKHasNoINITs is set when the file contains no INIT resource
+	// (extensions and control panels only).
+	FFKHasNoINITs uint16 = 0x0080
+	// FFKHasBeenInited is cleared if the file contains desktop database
+	// resources ('BNDL', 'FREF', 'open', 'kind'...) that have not been added
+	// yet. Set only by the Finder, reserved for folders (files only).
+	FFKHasBeenInited uint16 = 0x0100
+	// FFKHasCustomIcon is set when the file or folder has a custom icon.
+	FFKHasCustomIcon uint16 = 0x0400
+	// FFKIsStationery flags stationery pads (files only).
+	FFKIsStationery uint16 = 0x0800
+	// FFKNameLocked prevents the file or folder from being renamed.
+	FFKNameLocked uint16 = 0x1000
+	// FFKHasBundle indicates that a file has a BNDL resource or that a folder
+	// is displayed as a package.
+	FFKHasBundle uint16 = 0x2000
+	// FFKIsInvisible hides the file or folder in Finder.
+	FFKIsInvisible uint16 = 0x4000
+	// FFKIsAlias flags alias files (files only).
+	FFKIsAlias uint16 = 0x8000
+)
+
+// Finder type and creator codes of alias files (from Finder.h)
+const (
+	KContainerFolderAliasType = 0x66647270 // 'fdrp'
+	KAliasFileType            = 0x616c6973 // 'alis', used for aliases to files
+	KSystemCreator            = 0x4d414353 // 'MACS'
+)
+
+// extended attributes options (from sys/xattr.h)
+const (
+	XATTR_NOFOLLOW = 0x0001 // Don't follow symbolic links
 )
@@ -3,8 +3,15 @@
 package darwin
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 )
@@ -15,23 +22,87 @@ const (
 )
 
 var (
+	// Debug prints the attributes GetAttrList skips to stderr.
+	Debug bool
 	// Epoch is the darwin epoch instead of unix'
 	Epoch = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	// ErrAttrBufTooSmall is returned by GetAttrList when the attributes don't
+	// fit in the passed buffer, see AttrBufSize.
+	ErrAttrBufTooSmall = errors.New("attribute buffer too small")
 )
 
 type AttrList struct {
 	Name               string
-	FileID             uint32
+	FileID             uint64
+	ParentID           uint64 // file ID of the parent folder
+	FullPath           string // absolute path, resolved by the file system
 	ReturnedAttributes *AttrSet
 	CreationTime       *TimeSpec
+	ModTime            *TimeSpec // last data modification
+	ChgTime            *TimeSpec // last attribute modification
+	AccTime            *TimeSpec // last access
 	VolName            string
 	VolSize            int64
+	VolSpaceFree       int64 // free bytes, including the ones reserved for the super user
+	VolSpaceAvail      int64 // bytes available to the calling user
 	VolUUID            [16]byte
+	VolCapabilities    VolCapabilities
+	MountFlags         uint32 // MNT_* flags the volume was mounted with
+	Flags              uint32 // UF_* and SF_* file flags (st_flags)
 	ObjType            uint32
 	FileInfo           FileInfo
 	FolderInfo         FolderInfo
 	UUID               [16]byte
 	DevID              uint32
+	DataLength         int64 // logical size of the data fork
+	RsrcLength         int64 // logical size of the resource fork
+	// ExtendedSecurity is the raw kauth_filesec data holding the ACL of the
+	// object (ATTR_CMN_EXTENDED_SECURITY).
+	ExtendedSecurity []byte
+}
+
+// VolCapabilities mirrors vol_capabilities_attr_t, the capabilities of a
+// volume (ATTR_VOL_CAPABILITIES). Each set is indexed by
+// VOL_CAPABILITIES_FORMAT or VOL_CAPABILITIES_INTERFACES, a capability is
+// only meaningful if the file system sets its bit in Valid.
+type VolCapabilities struct {
+	Capabilities [4]uint32
+	Valid        [4]uint32
+}
+
+// has reports whether all the passed capabilities of the set are valid and
+// supported.
+func (c VolCapabilities) has(set int, capabilities uint32) bool {
+	return c.Valid[set]&capabilities == capabilities &&
+		c.Capabilities[set]&capabilities == capabilities
+}
+
+// HasFormat reports whether the volume format supports the passed
+// VOL_CAP_FMT_* capabilities.
+func (c VolCapabilities) HasFormat(capabilities uint32) bool {
+	return c.has(VOL_CAPABILITIES_FORMAT, capabilities)
+}
+
+// HasInterface reports whether the file system implements the passed
+// VOL_CAP_INT_* capabilities.
+func (c VolCapabilities) HasInterface(capabilities uint32) bool {
+	return c.has(VOL_CAPABILITIES_INTERFACES, capabilities)
+}
+
+// SupportsPersistentIDs reports whether the file IDs of the volume are
+// persistent across mounts, so objects can be found by CNID.
+func (c VolCapabilities) SupportsPersistentIDs() bool {
+	return c.HasFormat(VOL_CAP_FMT_PERSISTENTOBJECTIDS)
+}
+
+// SupportsSymlinks reports whether the volume supports symbolic links.
+func (c VolCapabilities) SupportsSymlinks() bool {
+	return c.HasFormat(VOL_CAP_FMT_SYMBOLICLINKS)
+}
+
+// SupportsHardLinks reports whether the volume supports hard links to files.
+func (c VolCapabilities) SupportsHardLinks() bool {
+	return c.HasFormat(VOL_CAP_FMT_HARDLINKS)
 }
 
 // StringVolUUID returns a string formatted version of the volume UUID
@@ -45,6 +116,124 @@ func (attr *AttrList) IsFolder() bool {
 	return attr.ObjType == VDIR
 }
 
+// IsHidden indicates if the object is flagged as hidden (UF_HIDDEN).
+// ATTR_CMN_FLAGS must have been ask as a common attribute to check this flag.
+func (attr *AttrList) IsHidden() bool {
+	return attr.Flags&UF_HIDDEN > 0
+}
+
+// IsImmutable indicates if the object can't be changed (UF_IMMUTABLE or
+// SF_IMMUTABLE).
+// ATTR_CMN_FLAGS must have been ask as a common attribute to check this flag.
+func (attr *AttrList) IsImmutable() bool {
+	return attr.Flags&(UF_IMMUTABLE|SF_IMMUTABLE) > 0
+}
+
+// String returns a human readable dump of the attribute list, meant for
+// debugging getattrlist results.
+func (attr *AttrList) String() string {
+	var b strings.Builder
+	b.Grow(256)
+	b.WriteString("Name: ")
+	b.WriteString(attr.Name)
+	b.WriteString(", FileID: ")
+	b.WriteString(strconv.FormatUint(attr.FileID, 10))
+	b.WriteString(", ObjType: ")
+	b.WriteString(vnodeTypeName(attr.ObjType))
+	b.WriteString(", UUID: ")
+	b.WriteString(toUUIDString(attr.UUID))
+	b.WriteString(", DevID: ")
+	b.WriteString(strconv.FormatUint(uint64(attr.DevID), 10))
+	if attr.CreationTime != nil {
+		b.WriteString(", CreationTime: ")
+		b.WriteString(attr.CreationTime.Time().UTC().Format(time.RFC3339))
+	}
+	if attr.ModTime != nil {
+		b.WriteString(", ModTime: ")
+		b.WriteString(attr.ModTime.Time().UTC().Format(time.RFC3339))
+	}
+	if attr.ChgTime != nil {
+		b.WriteString(", ChgTime: ")
+		b.WriteString(attr.ChgTime.Time().UTC().Format(time.RFC3339))
+	}
+	if attr.AccTime != nil {
+		b.WriteString(", AccTime: ")
+		b.WriteString(attr.AccTime.Time().UTC().Format(time.RFC3339))
+	}
+	flags := attr.FileInfo.FinderFlags
+	if attr.IsFolder() {
+		flags = attr.FolderInfo.FinderFlags
+	}
+	b.WriteString(", FinderFlags: 0x")
+	b.WriteString(strconv.FormatUint(uint64(flags), 16))
+	if flags != 0 {
+		b.WriteString(" (")
+		writeFinderFlagNames(&b, flags)
+		b.WriteByte(')')
+	}
+	if attr.VolName != "" {
+		b.WriteString(", VolName: ")
+		b.WriteString(attr.VolName)
+		b.WriteString(", VolSize: ")
+		b.WriteString(strconv.FormatInt(attr.VolSize, 10))
+		b.WriteString(", VolUUID: ")
+		b.WriteString(toUUIDString(attr.VolUUID))
+	}
+	return b.String()
+}
+
+var vnodeTypeNames = [...]string{
+	VNON:  "VNON",
+	VREG:  "VREG",
+	VDIR:  "VDIR",
+	VBLK:  "VBLK",
+	VCHR:  "VCHR",
+	VLNK:  "VLNK",
+	VSOCK: "VSOCK",
+	VFIFO: "VFIFO",
+	VBAD:  "VBAD",
+	VSTR:  "VSTR",
+	VCPLX: "VCPLX",
+}
+
+func vnodeTypeName(t uint32) string {
+	if t < uint32(len(vnodeTypeNames)) {
+		return vnodeTypeNames[t]
+	}
+	return "unknown(" + strconv.FormatUint(uint64(t), 10) + ")"
+}
+
+var finderFlagNames = []struct {
+	flag uint16
+	name string
+}{
+	{FFKIsOnDesk, "IsOnDesk"},
+	{FFKColor, "Color"},
+	{FFKIsShared, "IsShared"},
+	{FFKHasNoINITs, "HasNoINITs"},
+	{FFKHasBeenInited, "HasBeenInited"},
+	{FFKHasCustomIcon, "HasCustomIcon"},
+	{FFKIsStationery, "IsStationery"},
+	{FFKNameLocked, "NameLocked"},
+	{FFKHasBundle, "HasBundle"},
+	{FFKIsInvisible, "IsInvisible"},
+	{FFKIsAlias, "IsAlias"},
+}
+
+func writeFinderFlagNames(b *strings.Builder, flags uint16) {
+	first := true
+	for _, f := range finderFlagNames {
+		if flags&f.flag == 0 {
+			continue
+		}
+		if !first {
+			b.WriteByte('|')
+		}
+		b.WriteString(f.name)
+		first = false
+	}
+}
+
 // AttrListMask is a structure defined in <sys/attr.h> and used by GetAttrList
 // http://www.manpagez.com/man/2/getattrlist/
 type AttrListMask struct {
@@ -92,6 +281,27 @@ type Point struct {
 	Y int16
 }
 
+// NewPoint converts an image.Point into a Point, the coordinates are clamped
+// to the int16 range.
+func NewPoint(pt image.Point) Point {
+	return Point{X: clampInt16(pt.X), Y: clampInt16(pt.Y)}
+}
+
+// ImagePoint converts the point into an image.Point.
+func (p Point) ImagePoint() image.Point {
+	return image.Pt(int(p.X), int(p.Y))
+}
+
+func clampInt16(n int) int16 {
+	if n > math.MaxInt16 {
+		return math.MaxInt16
+	}
+	if n < math.MinInt16 {
+		return math.MinInt16
+	}
+	return int16(n)
+}
+
 type Rect struct {
 	X int16
 	Y int16
@@ -99,6 +309,25 @@ type Rect struct {
 	H int16
 }
 
+// FourCharCode is a 32 bit code made of 4 ASCII characters such as the
+// Finder file type and creator codes.
+type FourCharCode uint32
+
+// ParseFourCharCode converts a 4 character string such as "alis" into a
+// FourCharCode.
+func ParseFourCharCode(s string) (FourCharCode, error) {
+	if len(s) != 4 {
+		return 0, fmt.Errorf("%q isn't a four char code", s)
+	}
+	return FourCharCode(binary.BigEndian.Uint32([]byte(s))), nil
+}
+
+func (c FourCharCode) String() string {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(c))
+	return string(b)
+}
+
 // FileInfo structure (32 bytes)
 // See https://opensource.apple.com/source/CarbonHeaders/CarbonHeaders-9A581/Finder.h
 type FileInfo struct {
@@ -113,6 +342,15 @@ type FileInfo struct {
 	PutAwayFolderID     int32
 }
 
+// EncodeFinderInfo converts the file info into the 32 bytes stored in the
+// com.apple.FinderInfo extended attribute. Multibyte fields are always stored
+// big endian.
+func EncodeFinderInfo(info FileInfo) []byte {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.BigEndian, info)
+	return buf.Bytes()
+}
+
 type FolderInfo struct {
 	WindowBounds        Rect
 	FinderFlags         uint16
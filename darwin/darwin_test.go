@@ -0,0 +1,97 @@
+package darwin
+
+import (
+	"bytes"
+	"image"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEncodeFinderInfo(t *testing.T) {
+	info := FileInfo{
+		FileType:    0x616c6973, // alis
+		FileCreator: 0x4d414353, // MACS
+		FinderFlags: FFKIsAlias,
+		Location:    Point{X: 12, Y: -34},
+	}
+	want := []byte{0x61, 0x6c, 0x69, 0x73, 0x4d, 0x41, 0x43, 0x53, 0x80, 0, 0, 0x0c, 0xff, 0xde, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	if got := EncodeFinderInfo(info); !bytes.Equal(got, want) {
+		t.Errorf("EncodeFinderInfo() = %#v, want %#v", got, want)
+	}
+}
+
+func TestFourCharCode(t *testing.T) {
+	code, err := ParseFourCharCode("alis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != KAliasFileType {
+		t.Errorf("ParseFourCharCode(alis) = %#x, want %#x", uint32(code), KAliasFileType)
+	}
+	if code.String() != "alis" {
+		t.Errorf("FourCharCode.String() = %s, want alis", code)
+	}
+	if _, err := ParseFourCharCode("alias"); err == nil {
+		t.Error("expected an error for a 5 character code")
+	}
+}
+
+func TestAttrList_String(t *testing.T) {
+	attr := &AttrList{
+		Name:         "file.txt",
+		FileID:       42,
+		ObjType:      VREG,
+		UUID:         [16]byte{0xde, 0xad, 0xbe, 0xef, 0, 1, 0, 2, 0, 3, 0, 4, 5, 6, 7, 8},
+		CreationTime: &TimeSpec{Sec: 1500000000},
+		FileInfo:     FileInfo{FinderFlags: FFKIsAlias | FFKHasCustomIcon},
+	}
+	want := "Name: file.txt, FileID: 42, ObjType: VREG, UUID: deadbeef-0001-0002-0003-000405060708, DevID: 0, " +
+		"CreationTime: 2017-07-14T02:40:00Z, FinderFlags: 0x8400 (HasCustomIcon|IsAlias)"
+	if got := attr.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	folder := &AttrList{ObjType: VDIR, FolderInfo: FolderInfo{FinderFlags: FFKIsInvisible}}
+	if got := folder.String(); !strings.Contains(got, "ObjType: VDIR") || !strings.Contains(got, "FinderFlags: 0x4000 (IsInvisible)") {
+		t.Errorf("unexpected folder dump %q", got)
+	}
+}
+
+func TestPoint_ImagePoint(t *testing.T) {
+	tests := []struct {
+		in   image.Point
+		want image.Point
+	}{
+		{image.Pt(12, -34), image.Pt(12, -34)},
+		{image.Pt(0, 0), image.Pt(0, 0)},
+		{image.Pt(math.MaxInt16, math.MinInt16), image.Pt(math.MaxInt16, math.MinInt16)},
+		{image.Pt(40000, -40000), image.Pt(math.MaxInt16, math.MinInt16)},
+	}
+	for _, tt := range tests {
+		if got := NewPoint(tt.in).ImagePoint(); got != tt.want {
+			t.Errorf("NewPoint(%v).ImagePoint() = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestVolCapabilities(t *testing.T) {
+	var caps VolCapabilities
+	caps.Valid[VOL_CAPABILITIES_FORMAT] = VOL_CAP_FMT_PERSISTENTOBJECTIDS | VOL_CAP_FMT_SYMBOLICLINKS | VOL_CAP_FMT_HARDLINKS
+	// case sensitivity is set but not flagged as valid
+	caps.Capabilities[VOL_CAPABILITIES_FORMAT] = VOL_CAP_FMT_SYMBOLICLINKS | VOL_CAP_FMT_CASE_SENSITIVE
+	caps.Valid[VOL_CAPABILITIES_INTERFACES] = VOL_CAP_INT_ATTRLIST | VOL_CAP_INT_CLONE
+	caps.Capabilities[VOL_CAPABILITIES_INTERFACES] = VOL_CAP_INT_ATTRLIST | VOL_CAP_INT_CLONE
+
+	if caps.SupportsPersistentIDs() || !caps.SupportsSymlinks() || caps.SupportsHardLinks() {
+		t.Errorf("unexpected format capabilities: persistent IDs %t, symlinks %t, hard links %t",
+			caps.SupportsPersistentIDs(), caps.SupportsSymlinks(), caps.SupportsHardLinks())
+	}
+	if caps.HasFormat(VOL_CAP_FMT_CASE_SENSITIVE) {
+		t.Error("HasFormat() = true for a capability which isn't valid")
+	}
+	if !caps.HasInterface(VOL_CAP_INT_ATTRLIST|VOL_CAP_INT_CLONE) || caps.HasInterface(VOL_CAP_INT_CLONE|VOL_CAP_INT_SNAPSHOT) {
+		t.Error("HasInterface() should only report the capabilities when all of them are supported")
+	}
+}
@@ -0,0 +1,96 @@
+//go:build !darwin
+
+package darwin
+
+/*
+	No op implementations of the darwin only features so the package can be
+	compiled on other platforms. Every exported function of the darwin only
+	files must have a stub here returning notDarwin.
+*/
+
+import "errors"
+
+var (
+	notDarwin = errors.New("Only implemented on Darwin")
+)
+
+// SetAsAlias flags the destination file as an alias.
+// This function doesn't verify that the file is actually an alias.
+// Don't use on the wrong file!
+func SetAsAlias(path string) error {
+	return notDarwin
+}
+
+// UnsetAlias clears the alias flag of the file, the rest of the finder info
+// is preserved.
+func UnsetAlias(path string) error {
+	return notDarwin
+}
+
+// GetFinderFlags returns the Finder flags (FFK*) of the file, 0 if the file
+// doesn't have any finder info.
+func GetFinderFlags(path string) (uint16, error) {
+	return 0, notDarwin
+}
+
+// SetFinderFlags replaces the Finder flags (FFK*) of the file, the rest of
+// the finder info is preserved.
+func SetFinderFlags(path string, flags uint16) error {
+	return notDarwin
+}
+
+// SetFinderInfo writes the passed file info in the com.apple.FinderInfo
+// extended attribute of the file, replacing the existing value.
+func SetFinderInfo(path string, info FileInfo) error {
+	return notDarwin
+}
+
+// Getxattr returns the value of the named extended attribute of the file.
+// Symbolic links are followed, see Lgetxattr.
+func Getxattr(path string, name string) ([]byte, error) {
+	return nil, notDarwin
+}
+
+// Lgetxattr is like Getxattr but doesn't follow symbolic links.
+func Lgetxattr(path string, name string) ([]byte, error) {
+	return nil, notDarwin
+}
+
+// GetxattrBuf reads the named extended attribute of the file into dest and
+// returns the size of the value.
+func GetxattrBuf(path string, name string, dest []byte, options int) (int, error) {
+	return 0, notDarwin
+}
+
+// Listxattr returns the names of the extended attributes of the file.
+// Symbolic links are followed, see Llistxattr.
+func Listxattr(path string) ([]string, error) {
+	return nil, notDarwin
+}
+
+// Llistxattr is like Listxattr but doesn't follow symbolic links.
+func Llistxattr(path string) ([]string, error) {
+	return nil, notDarwin
+}
+
+// Removexattr removes the named extended attribute from the file.
+func Removexattr(path string, name string) error {
+	return notDarwin
+}
+
+// GetAttrList returns attributes (that is, metadata) of file system objects. GetAttrList()
+// works on the file system object named by path. You can think of getattrlist() as a
+// seriously enhanced version of syscall.Stat.  The functions return attributes about
+// the specified file system object into the buffer specified by attrBuf and
+// attrBufSize.  The attrList parameter determines what attributes are returned.
+//
+// https://developer.apple.com/legacy/library/documentation/Darwin/Reference/ManPages/man2/getattrlist.2.html
+func GetAttrList(path string, mask AttrListMask, attrBuf []byte, options uint32) (results *AttrList, err error) {
+	return nil, notDarwin
+}
+
+// GetAttrByFileID returns the attributes of the file system object identified
+// by its file ID (CNID) on the volume mounted at volumePath.
+func GetAttrByFileID(volumePath string, fileID uint64, mask AttrListMask) (*AttrList, error) {
+	return nil, notDarwin
+}
@@ -0,0 +1,36 @@
+//go:build !darwin
+
+package darwin
+
+import "testing"
+
+// TestNonDarwinStubs makes sure every darwin only function has a stub so the
+// package keeps compiling on other platforms.
+func TestNonDarwinStubs(t *testing.T) {
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"SetAsAlias", func() error { return SetAsAlias("src") }},
+		{"UnsetAlias", func() error { return UnsetAlias("src") }},
+		{"GetFinderFlags", func() error { _, err := GetFinderFlags("src"); return err }},
+		{"SetFinderFlags", func() error { return SetFinderFlags("src", FFKIsInvisible) }},
+		{"SetFinderInfo", func() error { return SetFinderInfo("src", FileInfo{}) }},
+		{"Getxattr", func() error { _, err := Getxattr("src", "name"); return err }},
+		{"Lgetxattr", func() error { _, err := Lgetxattr("src", "name"); return err }},
+		{"GetxattrBuf", func() error { _, err := GetxattrBuf("src", "name", nil, 0); return err }},
+		{"Listxattr", func() error { _, err := Listxattr("src"); return err }},
+		{"Llistxattr", func() error { _, err := Llistxattr("src"); return err }},
+		{"Removexattr", func() error { return Removexattr("src", "name") }},
+		{"GetAttrList", func() error {
+			_, err := GetAttrList("src", AttrListMask{}, make([]byte, 256), 0)
+			return err
+		}},
+		{"GetAttrByFileID", func() error { _, err := GetAttrByFileID("/", 2, AttrListMask{}); return err }},
+	}
+	for _, tt := range tests {
+		if err := tt.call(); err != notDarwin {
+			t.Errorf("%s returned %v, want the not darwin error", tt.name, err)
+		}
+	}
+}
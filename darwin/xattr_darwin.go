@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"syscall"
 	"unsafe"
 )
@@ -49,6 +50,11 @@ func GetAttrList(path string, mask AttrListMask, attrBuf []byte, options uint32)
 
 	// binary.LittleEndian.Uint32(attrBuf)
 	size := *(*uint32)(unsafe.Pointer(&attrBuf[0]))
+	// the full size (including the length prefix) is reported, the attributes
+	// were truncated if it doesn't fit.
+	if int(size) > len(attrBuf) {
+		return results, fmt.Errorf("%w: %d bytes needed, got %d", ErrAttrBufTooSmall, size, len(attrBuf))
+	}
 	// dat is the section of attrBuf that contains valid data,
 	// without the 4 byte length header. All attribute offsets
 	// are relative to dat.
@@ -59,9 +65,21 @@ func GetAttrList(path string, mask AttrListMask, attrBuf []byte, options uint32)
 	}
 	r := bytes.NewReader(dat)
 	pos := func() int64 { return r.Size() - int64(r.Len()) }
+	// skip moves past an attribute that isn't decoded so the following
+	// attributes are read from the right offset.
+	skip := func(attr string, size int64) error {
+		if Debug {
+			fmt.Fprintln(os.Stderr, attr, "not decoded, skipping", size, "bytes")
+		}
+		_, err := r.Seek(size, io.SeekCurrent)
+		return err
+	}
 
 	if mask.CommonAttr&ATTR_CMN_RETURNED_ATTRS > 0 {
-		fmt.Println("ATTR_CMN_RETURNED_ATTRS not supported yet", pos())
+		results.ReturnedAttributes = &AttrSet{}
+		if err = binary.Read(r, binary.LittleEndian, results.ReturnedAttributes); err != nil {
+			return results, fmt.Errorf("failed reading ATTR_CMN_RETURNED_ATTRS - %s", err)
+		}
 	}
 
 	if mask.CommonAttr&ATTR_CMN_NAME > 0 {
@@ -95,7 +113,9 @@ func GetAttrList(path string, mask AttrListMask, attrBuf []byte, options uint32)
 	}
 
 	if mask.CommonAttr&ATTR_CMN_FSID > 0 {
-		fmt.Println("ATTR_CMN_FSID not supported yet", pos())
+		if err = skip("ATTR_CMN_FSID", commonAttrSizes[ATTR_CMN_FSID]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_CMN_FSID - %s", err)
+		}
 	}
 
 	if mask.CommonAttr&ATTR_CMN_OBJTYPE > 0 {
@@ -105,40 +125,55 @@ func GetAttrList(path string, mask AttrListMask, attrBuf []byte, options uint32)
 	}
 
 	if mask.CommonAttr&ATTR_CMN_OBJTAG > 0 {
-		fmt.Println("ATTR_CMN_OBJTAG not supported yet", pos())
+		if err = skip("ATTR_CMN_OBJTAG", commonAttrSizes[ATTR_CMN_OBJTAG]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_CMN_OBJTAG - %s", err)
+		}
 	}
 	if mask.CommonAttr&ATTR_CMN_OBJID > 0 {
-		fmt.Println("ATTR_CMN_OBJID not supported yet", pos())
+		if err = skip("ATTR_CMN_OBJID", commonAttrSizes[ATTR_CMN_OBJID]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_CMN_OBJID - %s", err)
+		}
 	}
 	if mask.CommonAttr&ATTR_CMN_OBJPERMANENTID > 0 {
-		fmt.Println("ATTR_CMN_OBJPERMANENTID not supported yet", pos())
+		if err = skip("ATTR_CMN_OBJPERMANENTID", commonAttrSizes[ATTR_CMN_OBJPERMANENTID]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_CMN_OBJPERMANENTID - %s", err)
+		}
 	}
 	if mask.CommonAttr&ATTR_CMN_PAROBJID > 0 {
-		fmt.Println("ATTR_CMN_PAROBJID not supported yet", pos())
+		if err = skip("ATTR_CMN_PAROBJID", commonAttrSizes[ATTR_CMN_PAROBJID]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_CMN_PAROBJID - %s", err)
+		}
 	}
 	if mask.CommonAttr&ATTR_CMN_SCRIPT > 0 {
-		fmt.Println("ATTR_CMN_SCRIPT not supported yet", pos())
+		if err = skip("ATTR_CMN_SCRIPT", commonAttrSizes[ATTR_CMN_SCRIPT]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_CMN_SCRIPT - %s", err)
+		}
 	}
+	// times are packed in bit order
 	if mask.CommonAttr&ATTR_CMN_CRTIME > 0 {
-		results.CreationTime = &TimeSpec{}
-		if err = binary.Read(r, binary.LittleEndian, &results.CreationTime.Sec); err != nil {
-			return results, fmt.Errorf("failed reading TTR_CMN_CRTIME sec - %s", err)
-		}
-		if err = binary.Read(r, binary.LittleEndian, &results.CreationTime.Nsec); err != nil {
-			return results, fmt.Errorf("failed reading TTR_CMN_CRTIME nsec - %s", err)
+		if results.CreationTime, err = readTimeSpec(r); err != nil {
+			return results, fmt.Errorf("failed reading ATTR_CMN_CRTIME - %s", err)
 		}
 	}
 	if mask.CommonAttr&ATTR_CMN_MODTIME > 0 {
-		fmt.Println("ATTR_CMN_MODTIME not supported yet", pos())
+		if results.ModTime, err = readTimeSpec(r); err != nil {
+			return results, fmt.Errorf("failed reading ATTR_CMN_MODTIME - %s", err)
+		}
 	}
 	if mask.CommonAttr&ATTR_CMN_CHGTIME > 0 {
-		fmt.Println("ATTR_CMN_CHGTIME not supported yet", pos())
+		if results.ChgTime, err = readTimeSpec(r); err != nil {
+			return results, fmt.Errorf("failed reading ATTR_CMN_CHGTIME - %s", err)
+		}
 	}
 	if mask.CommonAttr&ATTR_CMN_ACCTIME > 0 {
-		fmt.Println("ATTR_CMN_ACCTIME not supported yet", pos())
+		if results.AccTime, err = readTimeSpec(r); err != nil {
+			return results, fmt.Errorf("failed reading ATTR_CMN_ACCTIME - %s", err)
+		}
 	}
 	if mask.CommonAttr&ATTR_CMN_BKUPTIME > 0 {
-		fmt.Println("ATTR_CMN_BKUPTIME not supported yet", pos())
+		if err = skip("ATTR_CMN_BKUPTIME", commonAttrSizes[ATTR_CMN_BKUPTIME]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_CMN_BKUPTIME - %s", err)
+		}
 	}
 	if mask.CommonAttr&ATTR_CMN_FNDRINFO > 0 {
 		// (read/write) 32 bytes of data for use by the Finder.  Equivalent to the concatenation
@@ -160,22 +195,66 @@ func GetAttrList(path string, mask AttrListMask, attrBuf []byte, options uint32)
 		}
 	}
 	if mask.CommonAttr&ATTR_CMN_OWNERID > 0 {
-		fmt.Println("ATTR_CMN_OWNERID not supported yet", pos())
+		if err = skip("ATTR_CMN_OWNERID", commonAttrSizes[ATTR_CMN_OWNERID]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_CMN_OWNERID - %s", err)
+		}
 	}
 	if mask.CommonAttr&ATTR_CMN_GRPID > 0 {
-		fmt.Println("ATTR_CMN_GRPID not supported yet", pos())
+		if err = skip("ATTR_CMN_GRPID", commonAttrSizes[ATTR_CMN_GRPID]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_CMN_GRPID - %s", err)
+		}
 	}
 	if mask.CommonAttr&ATTR_CMN_ACCESSMASK > 0 {
-		fmt.Println("ATTR_CMN_ACCESSMASK not supported yet", pos())
+		if err = skip("ATTR_CMN_ACCESSMASK", commonAttrSizes[ATTR_CMN_ACCESSMASK]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_CMN_ACCESSMASK - %s", err)
+		}
 	}
 	if mask.CommonAttr&ATTR_CMN_FLAGS > 0 {
-		fmt.Println("ATTR_CMN_FLAGS not supported yet", pos())
+		if err = binary.Read(r, binary.LittleEndian, &results.Flags); err != nil {
+			return results, fmt.Errorf("failed to read the file flags - %s", err)
+		}
+	}
+	// with FSOPT_ATTR_CMN_EXTENDED the deprecated named attribute bits
+	// request ATTR_CMN_GEN_COUNT and ATTR_CMN_DOCUMENT_ID, otherwise nothing
+	// is returned for them.
+	if options&FSOPT_ATTR_CMN_EXTENDED > 0 {
+		if mask.CommonAttr&ATTR_CMN_NAMEDATTRCOUNT > 0 {
+			if err = skip("ATTR_CMN_GEN_COUNT", commonAttrSizes[ATTR_CMN_NAMEDATTRCOUNT]); err != nil {
+				return results, fmt.Errorf("failed to skip ATTR_CMN_GEN_COUNT - %s", err)
+			}
+		}
+		if mask.CommonAttr&ATTR_CMN_NAMEDATTRLIST > 0 {
+			if err = skip("ATTR_CMN_DOCUMENT_ID", commonAttrSizes[ATTR_CMN_NAMEDATTRLIST]); err != nil {
+				return results, fmt.Errorf("failed to skip ATTR_CMN_DOCUMENT_ID - %s", err)
+			}
+		}
 	}
 	if mask.CommonAttr&ATTR_CMN_USERACCESS > 0 {
-		fmt.Println("ATTR_CMN_USERACCESS not supported yet", pos())
+		if err = skip("ATTR_CMN_USERACCESS", commonAttrSizes[ATTR_CMN_USERACCESS]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_CMN_USERACCESS - %s", err)
+		}
 	}
 	if mask.CommonAttr&ATTR_CMN_EXTENDED_SECURITY > 0 {
-		fmt.Println("ATTR_CMN_EXTENDED_SECURITY not supported yet", pos())
+		// kauth_filesec structure containing the ACL, not parsed yet.
+		ref := AttrRef{}
+		if err = binary.Read(r, binary.LittleEndian, &ref); err != nil {
+			return results, fmt.Errorf("failed reading ATTR_CMN_EXTENDED_SECURITY ref - %s", err)
+		}
+		offsetPos := pos()
+		if ref.Len > 0 {
+			// move to the offset minus the size of AttrRef (8)
+			if _, err = r.Seek(int64(ref.Offset)-8, io.SeekCurrent); err != nil {
+				return results, fmt.Errorf("failed to skip to the extended security data - %s", err)
+			}
+			results.ExtendedSecurity = make([]byte, ref.Len)
+			if _, err = io.ReadFull(r, results.ExtendedSecurity); err != nil {
+				return results, fmt.Errorf("failed to read the extended security data - %s", err)
+			}
+		}
+		// move back to the original offset
+		if _, err = r.Seek(offsetPos, io.SeekStart); err != nil {
+			return results, fmt.Errorf("failed to skip back after reading the extended security data - %s", err)
+		}
 	}
 	if mask.CommonAttr&ATTR_CMN_UUID > 0 {
 		if err = binary.Read(r, binary.LittleEndian, &results.UUID); err != nil {
@@ -183,30 +262,59 @@ func GetAttrList(path string, mask AttrListMask, attrBuf []byte, options uint32)
 		}
 	}
 	if mask.CommonAttr&ATTR_CMN_GRPUUID > 0 {
-		fmt.Println("ATTR_CMN_GRPUUID not supported yet", pos())
+		if err = skip("ATTR_CMN_GRPUUID", commonAttrSizes[ATTR_CMN_GRPUUID]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_CMN_GRPUUID - %s", err)
+		}
 	}
 	if mask.CommonAttr&ATTR_CMN_FILEID > 0 {
 		if err = binary.Read(r, binary.LittleEndian, &results.FileID); err != nil {
 			return results, fmt.Errorf("failed to read file ID - %s", err)
 		}
-
 	}
 	if mask.CommonAttr&ATTR_CMN_PARENTID > 0 {
-		fmt.Println("ATTR_CMN_PARENTID not supported yet", pos())
+		if err = binary.Read(r, binary.LittleEndian, &results.ParentID); err != nil {
+			return results, fmt.Errorf("failed to read the parent ID - %s", err)
+		}
 	}
 	if mask.CommonAttr&ATTR_CMN_FULLPATH > 0 {
-		fmt.Println("ATTR_CMN_FULLPATH not supported yet", pos())
+		ref := AttrRef{}
+		if err = binary.Read(r, binary.LittleEndian, &ref); err != nil {
+			return results, fmt.Errorf("failed reading ATTR_CMN_FULLPATH ref - %s", err)
+		}
+		offsetPos := pos()
+		if ref.Len > 0 {
+			// move to the offset minus the size of AttrRef (8)
+			if _, err = r.Seek(int64(ref.Offset)-8, io.SeekCurrent); err != nil {
+				return results, fmt.Errorf("failed to skip to the full path - %s", err)
+			}
+			// len-1 because the string is null terminated
+			fullPath := make([]byte, ref.Len-1)
+			if _, err = io.ReadFull(r, fullPath); err != nil {
+				return results, fmt.Errorf("failed to read the full path - %s", err)
+			}
+			results.FullPath = string(fullPath)
+		}
+		// move back to the original offset
+		if _, err = r.Seek(offsetPos, io.SeekStart); err != nil {
+			return results, fmt.Errorf("failed to skip back after reading the full path - %s", err)
+		}
 	}
 	if mask.CommonAttr&ATTR_CMN_ADDEDTIME > 0 {
-		fmt.Println("ATTR_CMN_ADDEDTIME not supported yet", pos())
+		if err = skip("ATTR_CMN_ADDEDTIME", commonAttrSizes[ATTR_CMN_ADDEDTIME]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_CMN_ADDEDTIME - %s", err)
+		}
 	}
 
 	// Volume attributes
 	if mask.VolAttr&ATTR_VOL_FSTYPE > 0 {
-		fmt.Println("ATTR_VOL_FSTYPE not supported yet", pos())
+		if err = skip("ATTR_VOL_FSTYPE", volAttrSizes[ATTR_VOL_FSTYPE]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_VOL_FSTYPE - %s", err)
+		}
 	}
 	if mask.VolAttr&ATTR_VOL_SIGNATURE > 0 {
-		fmt.Println("ATTR_VOL_SIGNATURE not supported yet", pos())
+		if err = skip("ATTR_VOL_SIGNATURE", volAttrSizes[ATTR_VOL_SIGNATURE]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_VOL_SIGNATURE - %s", err)
+		}
 	}
 	if mask.VolAttr&ATTR_VOL_SIZE > 0 {
 		if err = binary.Read(r, binary.LittleEndian, &results.VolSize); err != nil {
@@ -214,34 +322,54 @@ func GetAttrList(path string, mask AttrListMask, attrBuf []byte, options uint32)
 		}
 	}
 	if mask.VolAttr&ATTR_VOL_SPACEFREE > 0 {
-		fmt.Println("ATTR_VOL_SPACEFREE not supported yet", pos())
+		if err = binary.Read(r, binary.LittleEndian, &results.VolSpaceFree); err != nil {
+			return results, fmt.Errorf("failed to read the free volume space - %s", err)
+		}
 	}
 	if mask.VolAttr&ATTR_VOL_SPACEAVAIL > 0 {
-		fmt.Println("ATTR_VOL_SPACEAVAIL not supported yet", pos())
+		if err = binary.Read(r, binary.LittleEndian, &results.VolSpaceAvail); err != nil {
+			return results, fmt.Errorf("failed to read the available volume space - %s", err)
+		}
 	}
 	if mask.VolAttr&ATTR_VOL_MINALLOCATION > 0 {
-		fmt.Println("ATTR_VOL_MINALLOCATION not supported yet", pos())
+		if err = skip("ATTR_VOL_MINALLOCATION", volAttrSizes[ATTR_VOL_MINALLOCATION]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_VOL_MINALLOCATION - %s", err)
+		}
 	}
 	if mask.VolAttr&ATTR_VOL_ALLOCATIONCLUMP > 0 {
-		fmt.Println("ATTR_VOL_ALLOCATIONCLUMP not supported yet", pos())
+		if err = skip("ATTR_VOL_ALLOCATIONCLUMP", volAttrSizes[ATTR_VOL_ALLOCATIONCLUMP]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_VOL_ALLOCATIONCLUMP - %s", err)
+		}
 	}
 	if mask.VolAttr&ATTR_VOL_IOBLOCKSIZE > 0 {
-		fmt.Println("ATTR_VOL_IOBLOCKSIZE not supported yet", pos())
+		if err = skip("ATTR_VOL_IOBLOCKSIZE", volAttrSizes[ATTR_VOL_IOBLOCKSIZE]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_VOL_IOBLOCKSIZE - %s", err)
+		}
 	}
 	if mask.VolAttr&ATTR_VOL_OBJCOUNT > 0 {
-		fmt.Println("ATTR_VOL_OBJCOUNT not supported yet", pos())
+		if err = skip("ATTR_VOL_OBJCOUNT", volAttrSizes[ATTR_VOL_OBJCOUNT]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_VOL_OBJCOUNT - %s", err)
+		}
 	}
 	if mask.VolAttr&ATTR_VOL_FILECOUNT > 0 {
-		fmt.Println("ATTR_VOL_FILECOUNT not supported yet", pos())
+		if err = skip("ATTR_VOL_FILECOUNT", volAttrSizes[ATTR_VOL_FILECOUNT]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_VOL_FILECOUNT - %s", err)
+		}
 	}
 	if mask.VolAttr&ATTR_VOL_DIRCOUNT > 0 {
-		fmt.Println("ATTR_VOL_DIRCOUNT not supported yet", pos())
+		if err = skip("ATTR_VOL_DIRCOUNT", volAttrSizes[ATTR_VOL_DIRCOUNT]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_VOL_DIRCOUNT - %s", err)
+		}
 	}
 	if mask.VolAttr&ATTR_VOL_MAXOBJCOUNT > 0 {
-		fmt.Println("ATTR_VOL_MAXOBJCOUNT not supported yet", pos())
+		if err = skip("ATTR_VOL_MAXOBJCOUNT", volAttrSizes[ATTR_VOL_MAXOBJCOUNT]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_VOL_MAXOBJCOUNT - %s", err)
+		}
 	}
 	if mask.VolAttr&ATTR_VOL_MOUNTPOINT > 0 {
-		fmt.Println("ATTR_VOL_MOUNTPOINT not supported yet", pos())
+		if err = skip("ATTR_VOL_MOUNTPOINT", volAttrSizes[ATTR_VOL_MOUNTPOINT]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_VOL_MOUNTPOINT - %s", err)
+		}
 	}
 	if mask.VolAttr&ATTR_VOL_NAME > 0 {
 		ref := AttrRef{}
@@ -266,16 +394,24 @@ func GetAttrList(path string, mask AttrListMask, attrBuf []byte, options uint32)
 
 	}
 	if mask.VolAttr&ATTR_VOL_MOUNTFLAGS > 0 {
-		fmt.Println("ATTR_VOL_MOUNTFLAGS not supported yet", pos())
+		if err = binary.Read(r, binary.LittleEndian, &results.MountFlags); err != nil {
+			return results, fmt.Errorf("failed to read the volume mount flags - %s", err)
+		}
 	}
 	if mask.VolAttr&ATTR_VOL_MOUNTEDDEVICE > 0 {
-		fmt.Println("ATTR_VOL_MOUNTEDDEVICE not supported yet", pos())
+		if err = skip("ATTR_VOL_MOUNTEDDEVICE", volAttrSizes[ATTR_VOL_MOUNTEDDEVICE]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_VOL_MOUNTEDDEVICE - %s", err)
+		}
 	}
 	if mask.VolAttr&ATTR_VOL_ENCODINGSUSED > 0 {
-		fmt.Println("ATTR_VOL_ENCODINGSUSED not supported yet", pos())
+		if err = skip("ATTR_VOL_ENCODINGSUSED", volAttrSizes[ATTR_VOL_ENCODINGSUSED]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_VOL_ENCODINGSUSED - %s", err)
+		}
 	}
 	if mask.VolAttr&ATTR_VOL_CAPABILITIES > 0 {
-		fmt.Println("ATTR_VOL_CAPABILITIES not supported yet", pos())
+		if err = binary.Read(r, binary.LittleEndian, &results.VolCapabilities); err != nil {
+			return results, fmt.Errorf("failed to read the volume capabilities - %s", err)
+		}
 	}
 	if mask.VolAttr&ATTR_VOL_UUID > 0 {
 		if err = binary.Read(r, binary.LittleEndian, &results.VolUUID); err != nil {
@@ -283,75 +419,249 @@ func GetAttrList(path string, mask AttrListMask, attrBuf []byte, options uint32)
 		}
 	}
 	if mask.VolAttr&ATTR_VOL_ATTRIBUTES > 0 {
-		fmt.Println("ATTR_VOL_ATTRIBUTES not supported yet", pos())
+		if err = skip("ATTR_VOL_ATTRIBUTES", volAttrSizes[ATTR_VOL_ATTRIBUTES]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_VOL_ATTRIBUTES - %s", err)
+		}
 	}
 
 	// Directory
 	if mask.DirAttr&ATTR_DIR_LINKCOUNT > 0 {
-		fmt.Println("ATTR_DIR_LINKCOUNT not supported yet", pos())
+		if err = skip("ATTR_DIR_LINKCOUNT", dirAttrSizes[ATTR_DIR_LINKCOUNT]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_DIR_LINKCOUNT - %s", err)
+		}
 	}
 	if mask.DirAttr&ATTR_DIR_ENTRYCOUNT > 0 {
-		fmt.Println("ATTR_DIR_ENTRYCOUNT not supported yet", pos())
+		if err = skip("ATTR_DIR_ENTRYCOUNT", dirAttrSizes[ATTR_DIR_ENTRYCOUNT]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_DIR_ENTRYCOUNT - %s", err)
+		}
 	}
 	if mask.DirAttr&ATTR_DIR_MOUNTSTATUS > 0 {
-		fmt.Println("ATTR_DIR_MOUNTSTATUS not supported yet", pos())
+		if err = skip("ATTR_DIR_MOUNTSTATUS", dirAttrSizes[ATTR_DIR_MOUNTSTATUS]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_DIR_MOUNTSTATUS - %s", err)
+		}
 	}
 
 	// File
 	if mask.FileAttr&ATTR_FILE_LINKCOUNT > 0 {
-		fmt.Println("ATTR_FILE_LINKCOUNT not supported yet", pos())
+		if err = skip("ATTR_FILE_LINKCOUNT", fileAttrSizes[ATTR_FILE_LINKCOUNT]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_FILE_LINKCOUNT - %s", err)
+		}
 	}
 	if mask.FileAttr&ATTR_FILE_TOTALSIZE > 0 {
-		fmt.Println("ATTR_FILE_TOTALSIZE not supported yet", pos())
+		if err = skip("ATTR_FILE_TOTALSIZE", fileAttrSizes[ATTR_FILE_TOTALSIZE]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_FILE_TOTALSIZE - %s", err)
+		}
 	}
 	if mask.FileAttr&ATTR_FILE_ALLOCSIZE > 0 {
-		fmt.Println("ATTR_FILE_ALLOCSIZE not supported yet", pos())
+		if err = skip("ATTR_FILE_ALLOCSIZE", fileAttrSizes[ATTR_FILE_ALLOCSIZE]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_FILE_ALLOCSIZE - %s", err)
+		}
 	}
 	if mask.FileAttr&ATTR_FILE_IOBLOCKSIZE > 0 {
-		fmt.Println("ATTR_FILE_IOBLOCKSIZE not supported yet", pos())
+		if err = skip("ATTR_FILE_IOBLOCKSIZE", fileAttrSizes[ATTR_FILE_IOBLOCKSIZE]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_FILE_IOBLOCKSIZE - %s", err)
+		}
 	}
 	if mask.FileAttr&ATTR_FILE_CLUMPSIZE > 0 {
-		fmt.Println("ATTR_FILE_CLUMPSIZE not supported yet", pos())
+		if err = skip("ATTR_FILE_CLUMPSIZE", fileAttrSizes[ATTR_FILE_CLUMPSIZE]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_FILE_CLUMPSIZE - %s", err)
+		}
 	}
 	if mask.FileAttr&ATTR_FILE_DEVTYPE > 0 {
-		fmt.Println("ATTR_FILE_DEVTYPE not supported yet", pos())
+		if err = skip("ATTR_FILE_DEVTYPE", fileAttrSizes[ATTR_FILE_DEVTYPE]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_FILE_DEVTYPE - %s", err)
+		}
 	}
 	if mask.FileAttr&ATTR_FILE_FILETYPE > 0 {
-		fmt.Println("ATTR_FILE_FILETYPE not supported yet", pos())
+		if err = skip("ATTR_FILE_FILETYPE", fileAttrSizes[ATTR_FILE_FILETYPE]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_FILE_FILETYPE - %s", err)
+		}
 	}
 	if mask.FileAttr&ATTR_FILE_FORKCOUNT > 0 {
-		fmt.Println("ATTR_FILE_FORKCOUNT not supported yet", pos())
+		if err = skip("ATTR_FILE_FORKCOUNT", fileAttrSizes[ATTR_FILE_FORKCOUNT]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_FILE_FORKCOUNT - %s", err)
+		}
 	}
 	if mask.FileAttr&ATTR_FILE_DATALENGTH > 0 {
-		fmt.Println("ATTR_FILE_DATALENGTH not supported yet", pos())
+		if err = binary.Read(r, binary.LittleEndian, &results.DataLength); err != nil {
+			return results, fmt.Errorf("failed to read the data fork length - %s", err)
+		}
 	}
 	if mask.FileAttr&ATTR_FILE_DATAALLOCSIZE > 0 {
-		fmt.Println("ATTR_FILE_DATAALLOCSIZE not supported yet", pos())
+		if err = skip("ATTR_FILE_DATAALLOCSIZE", fileAttrSizes[ATTR_FILE_DATAALLOCSIZE]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_FILE_DATAALLOCSIZE - %s", err)
+		}
 	}
 	if mask.FileAttr&ATTR_FILE_DATAEXTENTS > 0 {
-		fmt.Println("ATTR_FILE_DATAEXTENTS not supported yet", pos())
+		if err = skip("ATTR_FILE_DATAEXTENTS", fileAttrSizes[ATTR_FILE_DATAEXTENTS]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_FILE_DATAEXTENTS - %s", err)
+		}
 	}
 	if mask.FileAttr&ATTR_FILE_RSRCLENGTH > 0 {
-		fmt.Println("ATTR_FILE_RSRCLENGTH not supported yet", pos())
+		if err = binary.Read(r, binary.LittleEndian, &results.RsrcLength); err != nil {
+			return results, fmt.Errorf("failed to read the resource fork length - %s", err)
+		}
 	}
 	if mask.FileAttr&ATTR_FILE_RSRCALLOCSIZE > 0 {
-		fmt.Println("ATTR_FILE_RSRCALLOCSIZE not supported yet", pos())
+		if err = skip("ATTR_FILE_RSRCALLOCSIZE", fileAttrSizes[ATTR_FILE_RSRCALLOCSIZE]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_FILE_RSRCALLOCSIZE - %s", err)
+		}
 	}
 	if mask.FileAttr&ATTR_FILE_RSRCEXTENTS > 0 {
-		fmt.Println("ATTR_FILE_RSRCEXTENTS not supported yet", pos())
+		if err = skip("ATTR_FILE_RSRCEXTENTS", fileAttrSizes[ATTR_FILE_RSRCEXTENTS]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_FILE_RSRCEXTENTS - %s", err)
+		}
 	}
 
 	// fork
 	if mask.ForkAttr&ATTR_FORK_TOTALSIZE > 0 {
-		fmt.Println("ATTR_FORK_TOTALSIZE not supported yet", pos())
+		if err = skip("ATTR_FORK_TOTALSIZE", forkAttrSizes[ATTR_FORK_TOTALSIZE]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_FORK_TOTALSIZE - %s", err)
+		}
 	}
 	if mask.ForkAttr&ATTR_FORK_ALLOCSIZE > 0 {
-		fmt.Println("ATTR_FORK_ALLOCSIZE not supported yet", pos())
+		if err = skip("ATTR_FORK_ALLOCSIZE", forkAttrSizes[ATTR_FORK_ALLOCSIZE]); err != nil {
+			return results, fmt.Errorf("failed to skip ATTR_FORK_ALLOCSIZE - %s", err)
+		}
 	}
 
 	return
 }
 
+// readTimeSpec reads a struct timespec attribute.
+func readTimeSpec(r io.Reader) (*TimeSpec, error) {
+	ts := &TimeSpec{}
+	if err := binary.Read(r, binary.LittleEndian, &ts.Sec); err != nil {
+		return nil, fmt.Errorf("sec - %s", err)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &ts.Nsec); err != nil {
+		return nil, fmt.Errorf("nsec - %s", err)
+	}
+	return ts, nil
+}
+
+// GetAttrByFileID returns the attributes of the file system object identified
+// by its file ID (CNID) on the volume mounted at volumePath (any path on the
+// volume can be used to identify it).
+// The object is looked up via the volfs path (/.vol/<volume device id>/<file id>)
+// which getattrlist() resolves by ID, without needing to know the object's path.
+// The volume must support persistent object IDs (HFS+, APFS).
+func GetAttrByFileID(volumePath string, fileID uint64, mask AttrListMask) (*AttrList, error) {
+	var stat syscall.Stat_t
+	if err := syscall.Stat(volumePath, &stat); err != nil {
+		return nil, fmt.Errorf("failed to stat the volume %s - %s", volumePath, err)
+	}
+	buf := make([]byte, 1024)
+	return GetAttrList(fmt.Sprintf("/.vol/%d/%d", stat.Dev, fileID), mask, buf, FSOPT_NOFOLLOW)
+}
+
+// Getxattr returns the value of the named extended attribute of the file.
+// Symbolic links are followed, see Lgetxattr.
+// syscall.ENOATTR is returned if the file doesn't have the attribute.
+func Getxattr(path string, name string) ([]byte, error) {
+	return getxattr(path, name, 0)
+}
+
+// Lgetxattr is like Getxattr but doesn't follow symbolic links.
+func Lgetxattr(path string, name string) ([]byte, error) {
+	return getxattr(path, name, XATTR_NOFOLLOW)
+}
+
+func getxattr(path string, name string, options int) ([]byte, error) {
+	for {
+		// size the value first
+		size, err := GetxattrBuf(path, name, nil, options)
+		if err != nil {
+			return nil, err
+		}
+		dest := make([]byte, size)
+		n, err := GetxattrBuf(path, name, dest, options)
+		if err == syscall.ERANGE {
+			// the value grew since it was sized
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return dest[:n], nil
+	}
+}
+
+// Listxattr returns the names of the extended attributes of the file.
+// Symbolic links are followed, see Llistxattr.
+func Listxattr(path string) ([]string, error) {
+	return listxattr(path, 0)
+}
+
+// Llistxattr is like Listxattr but doesn't follow symbolic links.
+func Llistxattr(path string) ([]string, error) {
+	return listxattr(path, XATTR_NOFOLLOW)
+}
+
+func listxattr(path string, options int) ([]string, error) {
+	for {
+		// size the list first
+		size, err := listxattrBuf(path, nil, options)
+		if err != nil {
+			return nil, err
+		}
+		dest := make([]byte, size)
+		n, err := listxattrBuf(path, dest, options)
+		if err == syscall.ERANGE {
+			// an attribute was added since the list was sized
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// the names are null terminated
+		var names []string
+		for _, name := range bytes.Split(dest[:n], []byte{0}) {
+			if len(name) > 0 {
+				names = append(names, string(name))
+			}
+		}
+		return names, nil
+	}
+}
+
+func listxattrBuf(path string, dest []byte, options int) (int, error) {
+	var names *byte
+	if len(dest) > 0 {
+		names = &dest[0]
+	}
+	n, _, e1 := syscall.Syscall6(syscall.SYS_LISTXATTR, uintptr(unsafe.Pointer(syscall.StringBytePtr(path))), uintptr(unsafe.Pointer(names)), uintptr(len(dest)), uintptr(options), 0, 0)
+	if e1 != syscall.Errno(0) {
+		return 0, e1
+	}
+	return int(n), nil
+}
+
+// GetxattrBuf reads the named extended attribute of the file into dest and
+// returns the size of the value, the size needed is returned if dest is nil.
+// syscall.ENOATTR is returned if the file doesn't have the attribute and
+// syscall.ERANGE if dest is too small.
+func GetxattrBuf(path string, name string, dest []byte, options int) (int, error) {
+	var value *byte
+	if len(dest) > 0 {
+		value = &dest[0]
+	}
+	n, _, e1 := syscall.Syscall6(syscall.SYS_GETXATTR, uintptr(unsafe.Pointer(syscall.StringBytePtr(path))), uintptr(unsafe.Pointer(syscall.StringBytePtr(name))), uintptr(unsafe.Pointer(value)), uintptr(len(dest)), 0, uintptr(options))
+	if e1 != syscall.Errno(0) {
+		return 0, e1
+	}
+	return int(n), nil
+}
+
+// Removexattr removes the named extended attribute from the file.
+// syscall.ENOATTR is returned if the file doesn't have the attribute.
+func Removexattr(path string, name string) error {
+	if _, _, e1 := syscall.Syscall(syscall.SYS_REMOVEXATTR, uintptr(unsafe.Pointer(syscall.StringBytePtr(path))), uintptr(unsafe.Pointer(syscall.StringBytePtr(name))), 0); e1 != syscall.Errno(0) {
+		return e1
+	}
+	return nil
+}
+
 func setxattr(path string, name string, value *byte, size int, pos int, options int) error {
 	if _, _, e1 := syscall.Syscall6(syscall.SYS_SETXATTR, uintptr(unsafe.Pointer(syscall.StringBytePtr(path))), uintptr(unsafe.Pointer(syscall.StringBytePtr(name))), uintptr(unsafe.Pointer(value)), uintptr(size), uintptr(pos), uintptr(options)); e1 != syscall.Errno(0) {
 		return e1
@@ -0,0 +1,453 @@
+package darwin
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestGetAttrByFileID(t *testing.T) {
+	f, err := ioutil.TempFile("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	attrs, err := GetAttrList(f.Name(), AttrListMask{CommonAttr: ATTR_CMN_FILEID}, make([]byte, 256), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := GetAttrByFileID(filepath.Dir(f.Name()), attrs.FileID, AttrListMask{CommonAttr: ATTR_CMN_NAME})
+	if err != nil {
+		t.Fatalf("GetAttrByFileID() error = %v", err)
+	}
+	if want := filepath.Base(f.Name()); got.Name != want {
+		t.Errorf("GetAttrByFileID().Name = %s, want %s", got.Name, want)
+	}
+}
+
+func TestGetAttrList_skippedAttributes(t *testing.T) {
+	f, err := ioutil.TempFile("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	var stat syscall.Stat_t
+	if err := syscall.Stat(f.Name(), &stat); err != nil {
+		t.Fatal(err)
+	}
+	// ATTR_CMN_BKUPTIME isn't decoded and is packed before ATTR_CMN_FILEID.
+	mask := AttrListMask{CommonAttr: ATTR_CMN_BKUPTIME | ATTR_CMN_FILEID | ATTR_CMN_PARENTID}
+	attrs, err := GetAttrList(f.Name(), mask, make([]byte, 256), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attrs.FileID != stat.Ino {
+		t.Errorf("FileID = %d, want %d", attrs.FileID, stat.Ino)
+	}
+}
+
+func TestGetAttrList_forkLengths(t *testing.T) {
+	f, err := ioutil.TempFile("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString("cocoa"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	rsrc := []byte("resource fork")
+	if err := setxattr(f.Name(), "com.apple.ResourceFork", &rsrc[0], len(rsrc), 0, 0); err != nil {
+		t.Skipf("failed to write the resource fork - %s", err)
+	}
+
+	// ATTR_FILE_DATAALLOCSIZE isn't decoded and is packed between both lengths.
+	mask := AttrListMask{
+		CommonAttr: ATTR_CMN_FILEID,
+		FileAttr:   ATTR_FILE_DATALENGTH | ATTR_FILE_DATAALLOCSIZE | ATTR_FILE_RSRCLENGTH,
+	}
+	attrs, err := GetAttrList(f.Name(), mask, make([]byte, 256), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attrs.DataLength != 5 {
+		t.Errorf("DataLength = %d, want 5", attrs.DataLength)
+	}
+	if attrs.RsrcLength != int64(len(rsrc)) {
+		t.Errorf("RsrcLength = %d, want %d", attrs.RsrcLength, len(rsrc))
+	}
+}
+
+func TestGetAttrList_parentIDAndFullPath(t *testing.T) {
+	f, err := ioutil.TempFile("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	// the temp dir is usually behind a symlink, the full path is resolved
+	path, err := filepath.EvalSymlinks(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stat syscall.Stat_t
+	if err := syscall.Stat(filepath.Dir(path), &stat); err != nil {
+		t.Fatal(err)
+	}
+
+	mask := AttrListMask{CommonAttr: ATTR_CMN_NAME | ATTR_CMN_FILEID | ATTR_CMN_PARENTID | ATTR_CMN_FULLPATH}
+	attrs, err := GetAttrList(f.Name(), mask, make([]byte, AttrBufSize(mask)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attrs.ParentID != stat.Ino {
+		t.Errorf("ParentID = %d, want %d", attrs.ParentID, stat.Ino)
+	}
+	if attrs.FullPath != path {
+		t.Errorf("FullPath = %q, want %q", attrs.FullPath, path)
+	}
+	if want := filepath.Base(path); attrs.Name != want {
+		t.Errorf("Name = %q, want %q", attrs.Name, want)
+	}
+}
+
+func TestGetAttrList_flags(t *testing.T) {
+	f, err := ioutil.TempFile("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	mask := AttrListMask{CommonAttr: ATTR_CMN_FLAGS | ATTR_CMN_FILEID}
+	attrs, err := GetAttrList(f.Name(), mask, make([]byte, 256), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attrs.IsHidden() || attrs.IsImmutable() {
+		t.Errorf("Flags = %#x, expected a new file not to be hidden or immutable", attrs.Flags)
+	}
+
+	if err := syscall.Chflags(f.Name(), int(UF_HIDDEN)); err != nil {
+		t.Skipf("failed to hide the file - %s", err)
+	}
+	if attrs, err = GetAttrList(f.Name(), mask, make([]byte, 256), 0); err != nil {
+		t.Fatal(err)
+	}
+	if !attrs.IsHidden() {
+		t.Errorf("Flags = %#x, expected the file to be hidden", attrs.Flags)
+	}
+	if attrs.FileID == 0 {
+		t.Error("expected the file ID following the flags to be read")
+	}
+}
+
+func TestGetAttrList_extendedCommonAttributes(t *testing.T) {
+	f, err := ioutil.TempFile("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	var stat syscall.Stat_t
+	if err := syscall.Stat(f.Name(), &stat); err != nil {
+		t.Fatal(err)
+	}
+	// the generation count and document id are packed before ATTR_CMN_FILEID
+	// when requested with FSOPT_ATTR_CMN_EXTENDED and missing otherwise.
+	mask := AttrListMask{CommonAttr: ATTR_CMN_NAMEDATTRCOUNT | ATTR_CMN_NAMEDATTRLIST | ATTR_CMN_FILEID}
+	for _, options := range []uint32{0, FSOPT_ATTR_CMN_EXTENDED} {
+		attrs, err := GetAttrList(f.Name(), mask, make([]byte, AttrBufSize(mask)), options)
+		if err != nil {
+			t.Fatalf("GetAttrList(%#x) error = %v", options, err)
+		}
+		if attrs.FileID != stat.Ino {
+			t.Errorf("FileID = %d with options %#x, want %d", attrs.FileID, options, stat.Ino)
+		}
+	}
+}
+
+func TestGetAttrList_debug(t *testing.T) {
+	getAttrList := func() string {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		stderr := os.Stderr
+		os.Stderr = w
+		_, err = GetAttrList("/", AttrListMask{CommonAttr: ATTR_CMN_BKUPTIME}, make([]byte, 256), 0)
+		os.Stderr = stderr
+		w.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, _ := ioutil.ReadAll(r)
+		return string(out)
+	}
+
+	if out := getAttrList(); out != "" {
+		t.Errorf("GetAttrList() printed %q, expected no output", out)
+	}
+	Debug = true
+	defer func() { Debug = false }()
+	if out := getAttrList(); !strings.Contains(out, "ATTR_CMN_BKUPTIME") {
+		t.Errorf("GetAttrList() printed %q, expected the skipped attribute in debug mode", out)
+	}
+}
+
+func TestGetAttrList_bufferTooSmall(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := strings.Repeat("n", 250)
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mask := AttrListMask{CommonAttr: ATTR_CMN_NAME | ATTR_CMN_FILEID}
+	if _, err = GetAttrList(path, mask, make([]byte, 64), 0); !errors.Is(err, ErrAttrBufTooSmall) {
+		t.Errorf("expected ErrAttrBufTooSmall, got %v", err)
+	}
+	attrs, err := GetAttrList(path, mask, make([]byte, AttrBufSize(mask)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attrs.Name != name {
+		t.Errorf("Name = %s, want %s", attrs.Name, name)
+	}
+}
+
+func TestGetxattr_Listxattr_Removexattr(t *testing.T) {
+	f, err := ioutil.TempFile("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	if out, err := exec.Command("xattr", "-w", "com.apple.quarantine", "0081;5b63c0a4;Safari;", f.Name()).CombinedOutput(); err != nil {
+		t.Skipf("failed to set the attribute - %s %s", err, out)
+	}
+
+	buf := make([]byte, 64)
+	n, err := GetxattrBuf(f.Name(), "com.apple.quarantine", buf, 0)
+	if err != nil {
+		t.Fatalf("GetxattrBuf() error = %v", err)
+	}
+	if got := string(buf[:n]); got != "0081;5b63c0a4;Safari;" {
+		t.Errorf("GetxattrBuf() = %q, want the quarantine value", got)
+	}
+	if _, err := GetxattrBuf(f.Name(), "com.apple.quarantine", buf[:4], 0); err != syscall.ERANGE {
+		t.Errorf("expected ERANGE reading into a small buffer, got %v", err)
+	}
+
+	link := f.Name() + ".link"
+	if err := os.Symlink(f.Name(), link); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(link)
+	value, err := Getxattr(link, "com.apple.quarantine")
+	if err != nil {
+		t.Fatalf("Getxattr() error = %v", err)
+	}
+	if string(value) != "0081;5b63c0a4;Safari;" {
+		t.Errorf("Getxattr() = %q, want the quarantine value", value)
+	}
+	if _, err := Lgetxattr(link, "com.apple.quarantine"); err != syscall.ENOATTR {
+		t.Errorf("expected ENOATTR reading the attribute of the link itself, got %v", err)
+	}
+	names, err := Listxattr(link)
+	if err != nil {
+		t.Fatalf("Listxattr() error = %v", err)
+	}
+	// the system might add its own attributes
+	hasQuarantine := func(names []string) bool {
+		for _, name := range names {
+			if name == "com.apple.quarantine" {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasQuarantine(names) {
+		t.Errorf("Listxattr() = %q, expected com.apple.quarantine", names)
+	}
+	if names, err = Llistxattr(link); err != nil || hasQuarantine(names) {
+		t.Errorf("Llistxattr() = %q, %v, expected the attributes of the link itself", names, err)
+	}
+
+	if err := Removexattr(f.Name(), "com.apple.quarantine"); err != nil {
+		t.Fatalf("Removexattr() error = %v", err)
+	}
+	if _, err := Getxattr(f.Name(), "com.apple.quarantine"); err != syscall.ENOATTR {
+		t.Errorf("expected ENOATTR reading a removed attribute, got %v", err)
+	}
+	if err := Removexattr(f.Name(), "com.apple.quarantine"); err != syscall.ENOATTR {
+		t.Errorf("expected ENOATTR when removing a missing attribute, got %v", err)
+	}
+}
+
+func TestGetAttrList_times(t *testing.T) {
+	f, err := ioutil.TempFile("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	mtime := time.Date(2018, 8, 2, 17, 3, 12, 0, time.UTC)
+	atime := time.Date(2018, 8, 3, 9, 30, 0, 0, time.UTC)
+	if err := os.Chtimes(f.Name(), atime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	var stat syscall.Stat_t
+	if err := syscall.Stat(f.Name(), &stat); err != nil {
+		t.Fatal(err)
+	}
+	mask := AttrListMask{CommonAttr: ATTR_CMN_CRTIME | ATTR_CMN_MODTIME | ATTR_CMN_CHGTIME |
+		ATTR_CMN_ACCTIME | ATTR_CMN_BKUPTIME | ATTR_CMN_FILEID}
+	attrs, err := GetAttrList(f.Name(), mask, make([]byte, 256), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name string
+		got  *TimeSpec
+		want syscall.Timespec
+	}{
+		{"CreationTime", attrs.CreationTime, stat.Birthtimespec},
+		{"ModTime", attrs.ModTime, stat.Mtimespec},
+		{"ChgTime", attrs.ChgTime, stat.Ctimespec},
+		{"AccTime", attrs.AccTime, stat.Atimespec},
+	} {
+		if tt.got == nil {
+			t.Errorf("missing %s", tt.name)
+			continue
+		}
+		if tt.got.Sec != tt.want.Sec || tt.got.Nsec != tt.want.Nsec {
+			t.Errorf("%s = %s, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+	if !attrs.ModTime.Time().Equal(mtime) {
+		t.Errorf("ModTime = %v, want %v", attrs.ModTime.Time(), mtime)
+	}
+	if !attrs.AccTime.Time().Equal(atime) {
+		t.Errorf("AccTime = %v, want %v", attrs.AccTime.Time(), atime)
+	}
+	if attrs.FileID != stat.Ino {
+		t.Errorf("FileID = %d, want %d", attrs.FileID, stat.Ino)
+	}
+}
+
+func TestGetAttrList_skippedVolumeAttributes(t *testing.T) {
+	// ATTR_VOL_FSTYPE and ATTR_VOL_MINALLOCATION aren't decoded, the first
+	// one is packed before the volume size and the second one between the
+	// size and the name.
+	mask := AttrListMask{VolAttr: ATTR_VOL_FSTYPE | ATTR_VOL_SIZE | ATTR_VOL_MINALLOCATION | ATTR_VOL_NAME}
+	attrs, err := GetAttrList("/", mask, make([]byte, 1024), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attrs.VolSize <= 0 {
+		t.Errorf("VolSize = %d, want a positive size", attrs.VolSize)
+	}
+	if attrs.VolName == "" {
+		t.Error("missing volume name")
+	}
+}
+
+func TestGetAttrList_volumeSpace(t *testing.T) {
+	mask := AttrListMask{VolAttr: ATTR_VOL_SIZE | ATTR_VOL_SPACEFREE | ATTR_VOL_SPACEAVAIL}
+	attrs, err := GetAttrList("/", mask, make([]byte, AttrBufSize(mask)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attrs.VolSize <= 0 {
+		t.Errorf("VolSize = %d, want a positive size", attrs.VolSize)
+	}
+	if attrs.VolSpaceFree < 0 || attrs.VolSpaceFree > attrs.VolSize {
+		t.Errorf("VolSpaceFree = %d, want a value between 0 and the volume size %d", attrs.VolSpaceFree, attrs.VolSize)
+	}
+	if attrs.VolSpaceAvail < 0 || attrs.VolSpaceAvail > attrs.VolSpaceFree {
+		t.Errorf("VolSpaceAvail = %d, want a value between 0 and the free space %d", attrs.VolSpaceAvail, attrs.VolSpaceFree)
+	}
+}
+
+func TestGetAttrList_volumeCapabilities(t *testing.T) {
+	// the UUID is packed after the capabilities
+	mask := AttrListMask{VolAttr: ATTR_VOL_CAPABILITIES | ATTR_VOL_UUID}
+	attrs, err := GetAttrList("/", mask, make([]byte, AttrBufSize(mask)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caps := attrs.VolCapabilities
+	if !caps.SupportsPersistentIDs() || !caps.SupportsSymlinks() || !caps.SupportsHardLinks() {
+		t.Errorf("expected the root volume to support persistent IDs, symlinks and hard links, got %+v", caps)
+	}
+	if !caps.HasInterface(VOL_CAP_INT_ATTRLIST) {
+		t.Errorf("expected the root volume to support getattrlist, got %+v", caps)
+	}
+	if attrs.VolUUID == ([16]byte{}) {
+		t.Error("missing volume UUID")
+	}
+}
+
+func TestGetAttrList_extendedSecurity(t *testing.T) {
+	f, err := ioutil.TempFile("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	if out, err := exec.Command("chmod", "+a", "everyone deny delete", f.Name()).CombinedOutput(); err != nil {
+		t.Skipf("failed to set an ACL - %s %s", err, out)
+	}
+
+	var stat syscall.Stat_t
+	if err := syscall.Stat(f.Name(), &stat); err != nil {
+		t.Fatal(err)
+	}
+	mask := AttrListMask{CommonAttr: ATTR_CMN_EXTENDED_SECURITY | ATTR_CMN_FILEID}
+	attrs, err := GetAttrList(f.Name(), mask, make([]byte, 1024), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attrs.ExtendedSecurity) == 0 {
+		t.Error("missing extended security data")
+	}
+	if attrs.FileID != stat.Ino {
+		t.Errorf("FileID = %d, want %d", attrs.FileID, stat.Ino)
+	}
+}
+
+func TestGetAttrList_mountFlags(t *testing.T) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs("/", &stat); err != nil {
+		t.Fatal(err)
+	}
+	mask := AttrListMask{VolAttr: ATTR_VOL_NAME | ATTR_VOL_MOUNTFLAGS | ATTR_VOL_UUID}
+	attrs, err := GetAttrList("/", mask, make([]byte, 1024), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attrs.MountFlags&MNT_LOCAL == 0 {
+		t.Errorf("MountFlags = %#x, expected the root volume to be local", attrs.MountFlags)
+	}
+	if attrs.MountFlags&MNT_RDONLY != stat.Flags&MNT_RDONLY {
+		t.Errorf("MountFlags = %#x, statfs flags = %#x", attrs.MountFlags, stat.Flags)
+	}
+}
@@ -0,0 +1,24 @@
+package cocoa
+
+import (
+	"bytes"
+)
+
+// DiskImagePath returns the path of the disk image (.dmg) backing the volume
+// of the bookmark target, as found in the bookmark embedded under
+// KBookmarkVolumeBookmark. The disk image needs to be mounted before the
+// target can be resolved.
+// False is returned when the target isn't on a disk image or when the
+// embedded bookmark is stored in another TOC: the entries of the chained TOCs
+// are merged into the bookmark itself, they aren't extracted as a separate
+// bookmark.
+func (b *BookmarkData) DiskImagePath() (string, bool) {
+	if len(b.EmbeddedVolumeBookmark) == 0 {
+		return "", false
+	}
+	image, err := AliasFromReader(bytes.NewReader(b.EmbeddedVolumeBookmark))
+	if err != nil {
+		return "", false
+	}
+	return image.TargetPath(), true
+}
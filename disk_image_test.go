@@ -0,0 +1,75 @@
+package cocoa
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestBookmarkData_DiskImagePath(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   string
+		wantOK bool
+	}{
+		// file.wav inside of ~/Downloads/MattSplice.dmg mounted as /Volumes/MattSplice
+		{name: "disk image", input: "fixtures/diskImageAlias", want: "/Users/mattetti/Downloads/MattSplice.dmg", wantOK: true},
+		{name: "root volume", input: "fixtures/alias"},
+		{name: "external volume", input: "fixtures/exFATAlias"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			b, err := AliasFromReader(f)
+			if err != nil {
+				t.Fatalf("AliasFromReader() error = %v", err)
+			}
+			got, ok := b.DiskImagePath()
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("DiskImagePath() = %q, %t, want %q, %t", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestAliasFromReader_diskImageEntries(t *testing.T) {
+	f, err := os.Open("fixtures/diskImageAlias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	b, err := AliasFromReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.EmbeddedVolumeBookmark) == 0 {
+		t.Fatal("expected the embedded volume bookmark to be decoded")
+	}
+	if _, ok := b.Unknown[KBookmarkVolumeBookmark]; ok {
+		t.Error("the embedded volume bookmark shouldn't be kept as unknown")
+	}
+	b.VolumeMountPoint = "file:///Volumes/MattSplice/"
+
+	buf := &bytes.Buffer{}
+	if err := b.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := AliasFromReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.VolumeMountPoint != b.VolumeMountPoint {
+		t.Errorf("VolumeMountPoint = %q, want %q", got.VolumeMountPoint, b.VolumeMountPoint)
+	}
+	if !bytes.Equal(got.EmbeddedVolumeBookmark, b.EmbeddedVolumeBookmark) {
+		t.Error("the embedded volume bookmark didn't round trip")
+	}
+	if path, ok := got.DiskImagePath(); path != "/Users/mattetti/Downloads/MattSplice.dmg" || !ok {
+		t.Errorf("DiskImagePath() = %q, %t", path, ok)
+	}
+}
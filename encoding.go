@@ -46,11 +46,22 @@ func encodedTime(ts time.Time) []byte {
 	binary.Write(buf, binary.LittleEndian, uint32(8))
 	// type
 	binary.Write(buf, binary.LittleEndian, uint32(bmk_date|bmk_st_zero))
-	// data
-	binary.Write(buf, binary.BigEndian, float64(ts.Sub(darwin.Epoch).Seconds()))
+	// data, an unknown (zero) time is stored as the epoch
+	var secs float64
+	if !ts.IsZero() {
+		secs = ts.Sub(darwin.Epoch).Seconds()
+	}
+	binary.Write(buf, binary.BigEndian, secs)
 	return buf.Bytes()
 }
 
+func encodedUUID(uuid [16]byte) []byte {
+	buf := make([]byte, 8, 24)
+	binary.LittleEndian.PutUint32(buf, uint32(len(uuid)))
+	binary.LittleEndian.PutUint32(buf[4:], uint32(bmk_uuid|bmk_st_one))
+	return append(buf, uuid[:]...)
+}
+
 func encodedBool(v bool) []byte {
 	buf := make([]byte, 8)
 	if v {
@@ -0,0 +1,76 @@
+package cocoa
+
+import (
+	"encoding/binary"
+
+	"github.com/mattetti/cocoa/darwin"
+)
+
+// defaultFileProperties is the value of the FileProperties of a regular file:
+// the resource property flags followed by two masks of the valid properties.
+var defaultFileProperties = []byte{
+	0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x1f, 0x2, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x1f, 0x2, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+}
+
+// resourceKindFlags are the resource property flags describing the type of
+// the target.
+const resourceKindFlags = darwin.KCFURLResourceIsRegularFile |
+	darwin.KCFURLResourceIsDirectory |
+	darwin.KCFURLResourceIsSymbolicLink
+
+// setFilePropertyKind returns a copy of the passed file properties with the
+// kind flags of the first word matching the vnode type (darwin.VREG, VDIR...).
+// The other flags and property words are preserved. Invalid properties are
+// replaced by the default ones.
+func setFilePropertyKind(props []byte, objType uint32) []byte {
+	if len(props) < len(defaultFileProperties) {
+		props = defaultFileProperties
+	}
+	out := make([]byte, len(props))
+	copy(out, props)
+
+	var kind uint64
+	switch objType {
+	case darwin.VDIR:
+		kind = darwin.KCFURLResourceIsDirectory
+	case darwin.VLNK:
+		kind = darwin.KCFURLResourceIsSymbolicLink
+	default:
+		kind = darwin.KCFURLResourceIsRegularFile
+	}
+	flags := binary.LittleEndian.Uint64(out)
+	binary.LittleEndian.PutUint64(out, flags&^resourceKindFlags|kind)
+	return out
+}
+
+// propertyFlags returns the first word of the passed file or volume
+// properties, the property flags. Missing properties have no flags.
+func propertyFlags(props []byte) uint64 {
+	if len(props) < 8 {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(props)
+}
+
+// FileFlags returns the resource property flags of the target, see the
+// darwin.KCFURLResource* constants.
+func (b *BookmarkData) FileFlags() uint64 {
+	return propertyFlags(b.FileProperties)
+}
+
+// FileIsRegular reports whether the target is a regular file.
+func (b *BookmarkData) FileIsRegular() bool {
+	return b.FileFlags()&darwin.KCFURLResourceIsRegularFile != 0
+}
+
+// FileIsDirectory reports whether the target is a directory.
+func (b *BookmarkData) FileIsDirectory() bool {
+	return b.FileFlags()&darwin.KCFURLResourceIsDirectory != 0
+}
+
+// FileIsSymlink reports whether the target is a symbolic link.
+func (b *BookmarkData) FileIsSymlink() bool {
+	return b.FileFlags()&darwin.KCFURLResourceIsSymbolicLink != 0
+}
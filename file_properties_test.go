@@ -0,0 +1,59 @@
+package cocoa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/mattetti/cocoa/darwin"
+)
+
+func Test_setFilePropertyKind(t *testing.T) {
+	// hidden regular file with a custom second word
+	props := []byte{
+		0x81, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+		0x1f, 0x2, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+		0x3f, 0x2, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	}
+
+	dir := setFilePropertyKind(props, darwin.VDIR)
+	if flags := binary.LittleEndian.Uint64(dir); flags != darwin.KCFURLResourceIsDirectory|darwin.KCFURLResourceIsHidden {
+		t.Errorf("directory flags = %#x", flags)
+	}
+	if !bytes.Equal(dir[8:], props[8:]) {
+		t.Errorf("the other property words changed: %#v", dir[8:])
+	}
+	if props[0] != 0x81 {
+		t.Error("the passed properties were modified")
+	}
+
+	file := setFilePropertyKind(dir, darwin.VREG)
+	if !bytes.Equal(file, props) {
+		t.Errorf("file properties = %#v, want %#v", file, props)
+	}
+
+	if got := setFilePropertyKind(nil, darwin.VREG); !bytes.Equal(got, defaultFileProperties) {
+		t.Errorf("expected the default properties, got %#v", got)
+	}
+}
+
+func TestBookmarkData_fileFlags(t *testing.T) {
+	tests := []struct {
+		objType                  uint32
+		regular, directory, link bool
+	}{
+		{darwin.VREG, true, false, false},
+		{darwin.VDIR, false, true, false},
+		{darwin.VLNK, false, false, true},
+	}
+	for _, tt := range tests {
+		b := &BookmarkData{FileProperties: setFilePropertyKind(nil, tt.objType)}
+		if b.FileIsRegular() != tt.regular || b.FileIsDirectory() != tt.directory || b.FileIsSymlink() != tt.link {
+			t.Errorf("vnode type %d: FileIsRegular() = %t, FileIsDirectory() = %t, FileIsSymlink() = %t, want %t, %t, %t",
+				tt.objType, b.FileIsRegular(), b.FileIsDirectory(), b.FileIsSymlink(), tt.regular, tt.directory, tt.link)
+		}
+	}
+	if b := (&BookmarkData{FileProperties: []byte{0x1}}); b.FileFlags() != 0 || b.FileIsRegular() {
+		t.Errorf("FileFlags() = %#x, expected no flags for truncated properties", b.FileFlags())
+	}
+}
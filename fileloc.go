@@ -0,0 +1,95 @@
+package cocoa
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// FilelocBookmark reads the Finder location file (.fileloc or .webloc)
+// property list from the passed reader and returns the bookmark of its target.
+// The bookmark data is read from the URL dictionary or the root dictionary.
+// Location files only storing a file URL get a minimal bookmark of the URL
+// path.
+func FilelocBookmark(r io.Reader) (*BookmarkData, error) {
+	root, err := readPlist(r)
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := root.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid location file - the root isn't a dictionary")
+	}
+	var location string
+	switch u := dict["URL"].(type) {
+	case map[string]interface{}:
+		if data, ok := u["bookmark"].([]byte); ok {
+			return filelocBookmarkData(data)
+		}
+		location, _ = u["string"].(string)
+	case string:
+		location = u
+	}
+	if data, ok := dict["bookmark"].([]byte); ok {
+		return filelocBookmarkData(data)
+	}
+	if location == "" {
+		return nil, fmt.Errorf("invalid location file - no bookmark or URL found")
+	}
+	fileURL, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid location file URL %s - %s", location, err)
+	}
+	if fileURL.Scheme != "file" {
+		return nil, fmt.Errorf("%s isn't a file URL", location)
+	}
+	return MinimalBookmark(fileURL.Path)
+}
+
+func filelocBookmarkData(data []byte) (*BookmarkData, error) {
+	b, err := AliasFromReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the location file bookmark - %s", err)
+	}
+	return b, nil
+}
+
+// encodeFileloc returns the location file property list storing the passed
+// bookmark and the file URL of targetPath.
+func encodeFileloc(b *BookmarkData, targetPath string) ([]byte, error) {
+	data, err := b.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the bookmark - %s", err)
+	}
+	fileURL := &url.URL{Scheme: "file", Path: targetPath}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>URL</key>
+	<dict>
+		<key>bookmark</key>
+		<data>
+`)
+	// wrap the base64 data like Apple's tools do
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := 68
+		if n > len(encoded) {
+			n = len(encoded)
+		}
+		buf.WriteString("\t\t" + encoded[:n] + "\n")
+		encoded = encoded[n:]
+	}
+	buf.WriteString("\t\t</data>\n\t\t<key>string</key>\n\t\t<string>")
+	if err := xml.EscapeText(buf, []byte(fileURL.String())); err != nil {
+		return nil, err
+	}
+	buf.WriteString("</string>\n\t</dict>\n</dict>\n</plist>\n")
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,162 @@
+package cocoa
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilelocBookmark(t *testing.T) {
+	f, err := os.Open("fixtures/file.fileloc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := FilelocBookmark(f)
+	if err != nil {
+		t.Fatalf("FilelocBookmark() error = %v", err)
+	}
+
+	af, err := os.Open("fixtures/alias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer af.Close()
+	want, err := AliasFromReader(af)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FilelocBookmark() = %#v, want the bookmark of fixtures/alias %#v", got, want)
+	}
+}
+
+func TestFilelocBookmark_url(t *testing.T) {
+	tests := []struct {
+		name       string
+		plist      string
+		targetPath string
+		wantErr    bool
+	}{
+		{"file URL string",
+			`<plist version="1.0"><dict><key>URL</key><string>file:///Users/mattetti/My%20Notes.txt</string></dict></plist>`,
+			"/Users/mattetti/My Notes.txt", false},
+		{"file URL in the URL dictionary",
+			`<plist version="1.0"><dict><key>URL</key><dict><key>string</key><string>file:///Users/mattetti/Music/kick.wav</string></dict></dict></plist>`,
+			"/Users/mattetti/Music/kick.wav", false},
+		{"web location",
+			`<plist version="1.0"><dict><key>URL</key><string>https://github.com/mattetti/cocoa</string></dict></plist>`,
+			"", true},
+		{"no URL",
+			`<plist version="1.0"><dict><key>Name</key><string>file.txt</string></dict></plist>`,
+			"", true},
+		{"binary property list", "bplist00\xd1\x01\x02", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FilelocBookmark(strings.NewReader(tt.plist))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FilelocBookmark() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.TargetPath() != tt.targetPath {
+				t.Errorf("FilelocBookmark().TargetPath() = %s, want %s", got.TargetPath(), tt.targetPath)
+			}
+		})
+	}
+}
+
+func Test_encodeFileloc(t *testing.T) {
+	af, err := os.Open("fixtures/alias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer af.Close()
+	b, err := AliasFromReader(af)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := encodeFileloc(b, b.TargetPath())
+	if err != nil {
+		t.Fatalf("encodeFileloc() error = %v", err)
+	}
+	got, err := FilelocBookmark(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("FilelocBookmark() error = %v", err)
+	}
+	if got.TargetPath() != b.TargetPath() {
+		t.Errorf("FilelocBookmark().TargetPath() = %s, want %s", got.TargetPath(), b.TargetPath())
+	}
+	if !reflect.DeepEqual(got.CNIDPath, b.CNIDPath) {
+		t.Errorf("FilelocBookmark().CNIDPath = %#x, want %#x", got.CNIDPath, b.CNIDPath)
+	}
+
+	// the URL is used when the bookmark can't be read
+	root, err := readPlist(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := root.(map[string]interface{})["URL"].(map[string]interface{})
+	if want := "file://" + b.TargetPath(); u["string"] != want {
+		t.Errorf("URL string = %v, want %s", u["string"], want)
+	}
+}
+
+func Test_readPlist(t *testing.T) {
+	plist := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>array</key>
+	<array>
+		<integer>-42</integer>
+		<real>1.5</real>
+		<true/>
+		<false/>
+	</array>
+	<key>data</key>
+	<data>
+	Y29j
+	b2E=
+	</data>
+	<key>date</key>
+	<date>2018-08-02T17:03:12Z</date>
+	<key>empty</key>
+	<dict/>
+	<key>string</key>
+	<string>a &amp; b</string>
+</dict>
+</plist>`
+	got, err := readPlist(strings.NewReader(plist))
+	if err != nil {
+		t.Fatalf("readPlist() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"array":  []interface{}{int64(-42), 1.5, true, false},
+		"data":   []byte("cocoa"),
+		"date":   time.Date(2018, 8, 2, 17, 3, 12, 0, time.UTC),
+		"empty":  map[string]interface{}{},
+		"string": "a & b",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readPlist() = %#v, want %#v", got, want)
+	}
+
+	for _, bad := range []string{
+		"",
+		"<dict></dict>",
+		"<plist><dict><string>no key</string></dict></plist>",
+		"<plist><dict><key>missing value</key></dict></plist>",
+		"<plist><integer>one</integer></plist>",
+		"<plist><data>!!</data></plist>",
+	} {
+		if _, err := readPlist(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected an error reading %q", bad)
+		}
+	}
+}
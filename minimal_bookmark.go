@@ -0,0 +1,48 @@
+package cocoa
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// MinimalBookmark builds a minimal bookmark pointing to targetPath. Only the
+// path components, the volume path and the creation options are set so the
+// file system isn't accessed and the bookmark can be generated on any OS.
+// Paths under /Volumes/ are considered to be on the volume mounted there.
+func MinimalBookmark(targetPath string) (*BookmarkData, error) {
+	if targetPath == "" {
+		return nil, fmt.Errorf("empty target path")
+	}
+	target := filepath.ToSlash(targetPath)
+	if vol := filepath.VolumeName(targetPath); vol != "" {
+		target = target[len(vol):]
+	}
+	if !strings.HasPrefix(target, "/") {
+		abs, err := filepath.Abs(targetPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the path of the target - %s", err)
+		}
+		target = filepath.ToSlash(abs[len(filepath.VolumeName(abs)):])
+	}
+	target = path.Clean(target)
+
+	b := &BookmarkData{
+		Path:            pathComponents(target),
+		VolumePath:      "/",
+		VolumeIsRoot:    true,
+		VolumeURL:       "file:///",
+		CreationOptions: uint32(BookmarkCreationMinimalBookmark),
+		IsMinimal:       true,
+	}
+	if len(b.Path) > 1 && b.Path[0] == "Volumes" {
+		b.VolumePath = "/Volumes/" + b.Path[1]
+		b.VolumeIsRoot = false
+		b.VolumeURL = "file://" + b.VolumePath + "/"
+	}
+	if len(b.Path) > 1 {
+		b.ContainingFolderIDX = uint32(len(b.Path)) - 2
+	}
+	return b, nil
+}
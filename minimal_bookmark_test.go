@@ -0,0 +1,56 @@
+package cocoa
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMinimalBookmark(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		wantPath   []string
+		volumePath string
+	}{
+		{"root volume", "/Users/mattetti/Downloads/file.wav", []string{"Users", "mattetti", "Downloads", "file.wav"}, "/"},
+		{"external volume", "/Volumes/MattSplice/file.wav", []string{"Volumes", "MattSplice", "file.wav"}, "/Volumes/MattSplice"},
+		{"unclean path", "/Users//mattetti/./file.wav", []string{"Users", "mattetti", "file.wav"}, "/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := MinimalBookmark(tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			buf := &bytes.Buffer{}
+			if err := b.Write(buf); err != nil {
+				t.Fatal(err)
+			}
+			got, err := AliasFromReader(buf)
+			if err != nil {
+				t.Fatalf("AliasFromReader() error = %v", err)
+			}
+			if !got.IsMinimal || !got.CreationOptionFlags().IsMinimal() {
+				t.Errorf("expected a minimal bookmark, got creation options %#x", got.CreationOptions)
+			}
+			if !reflect.DeepEqual(got.Path, tt.wantPath) {
+				t.Errorf("Path = %#v, want %#v", got.Path, tt.wantPath)
+			}
+			if got.VolumePath != tt.volumePath {
+				t.Errorf("VolumePath = %s, want %s", got.VolumePath, tt.volumePath)
+			}
+			if want := filepath.Clean(tt.target); got.TargetPath() != want {
+				t.Errorf("TargetPath() = %s, want %s", got.TargetPath(), want)
+			}
+			if len(got.CNIDPath) != 0 || got.CNID != 0 {
+				t.Errorf("expected no CNIDs, got %v, %d", got.CNIDPath, got.CNID)
+			}
+		})
+	}
+
+	if _, err := MinimalBookmark(""); err == nil {
+		t.Error("expected an error for an empty target path")
+	}
+}
@@ -0,0 +1,119 @@
+//go:build !darwin
+
+package cocoa
+
+/*
+	No op implementations of the features so the package can be compiled
+	on other machines and godoc can work fine.
+
+	Every exported darwin only function or method must have a stub here
+	returning ErrNotDarwin, and be listed in non_darwin_noop_test.go.
+*/
+
+import (
+	"io"
+	"os"
+)
+
+// IsAlias returns positively if the passed file path is an alias.
+func IsAlias(src string) bool { return false }
+
+// IsAliasErr returns positively if the passed file path is an alias.
+func IsAliasErr(src string) (bool, error) { return false, ErrNotDarwin }
+
+// RemoveAlias clears the Finder alias flag of the file at path so it's no
+// longer considered an alias.
+func RemoveAlias(path string) error { return ErrNotDarwin }
+
+// Alias acts like os.Symlink but instead of creating a symlink, a bookmark is stored.
+func Alias(src, dst string) error { return ErrNotDarwin }
+
+// CreateAliasFile creates a Finder alias to target at dst.
+func CreateAliasFile(target, dst string) error {
+	return ErrNotDarwin
+}
+
+// NewBookmarkData gathers the volume and file attributes of the file found at
+// src and returns its bookmark without writing anything.
+func NewBookmarkData(src string) (*BookmarkData, error) {
+	return nil, ErrNotDarwin
+}
+
+// BookmarkTo writes the alias data of the file found at src to w.
+func BookmarkTo(src string, w io.Writer) error {
+	return ErrNotDarwin
+}
+
+// WriteFileloc writes a Finder location file (.fileloc) to dst pointing to
+// target.
+func WriteFileloc(target, dst string) error {
+	return ErrNotDarwin
+}
+
+// AliasWithOpts is like Alias but lets the caller customize how the bookmark
+// is created.
+func AliasWithOpts(src, dst string, opts BookmarkOpts) error {
+	return ErrNotDarwin
+}
+
+// ConvertSymlink replaces the symlink at the passed path by an alias to its
+// target.
+func ConvertSymlink(path string) error {
+	return ErrNotDarwin
+}
+
+// RelativeAlias writes an alias at dst pointing to targetPath with a URL
+// relative to fromDir.
+func RelativeAlias(targetPath, fromDir, dst string) error {
+	return ErrNotDarwin
+}
+
+// NewAliasRecord returns the alias record representation of a path
+func NewAliasRecord(path string) (*AliasRecord, error) {
+	return nil, ErrNotDarwin
+}
+
+// NewAliasRecordWithOpts is like NewAliasRecord but honors opts.Strict.
+func NewAliasRecordWithOpts(path string, opts BookmarkOpts) (*AliasRecord, error) {
+	return nil, ErrNotDarwin
+}
+
+// BookmarkFileInfo returns information about the bookmark file found at the
+// passed path.
+func BookmarkFileInfo(path string) (*BookmarkInfo, error) {
+	return nil, ErrNotDarwin
+}
+
+// OpenTarget opens the target of the alias file found at aliasPath for
+// reading.
+func OpenTarget(aliasPath string) (*os.File, error) {
+	return nil, ErrNotDarwin
+}
+
+// ResolveFileloc returns the path of the target of the Finder location file
+// (.fileloc or .webloc) found at path.
+func ResolveFileloc(path string) (string, error) {
+	return "", ErrNotDarwin
+}
+
+// Resolve returns the current on disk path of the bookmark target.
+func (b *BookmarkData) Resolve() (path string, stale bool, err error) {
+	return "", false, ErrNotDarwin
+}
+
+// Matches returns positively if the file at the passed path is the bookmark
+// target.
+func (b *BookmarkData) Matches(path string) (bool, error) {
+	return false, ErrNotDarwin
+}
+
+// IsStale reports whether the file found at the stored target path isn't the
+// bookmark target anymore.
+func (b *BookmarkData) IsStale() (bool, error) {
+	return false, ErrNotDarwin
+}
+
+// SetVolume moves the bookmark to the volume the passed path is on.
+func (b *BookmarkData) SetVolume(volumePath string) error {
+	return ErrNotDarwin
+}
@@ -0,0 +1,54 @@
+//go:build !darwin
+
+package cocoa
+
+import (
+	"errors"
+	"image"
+	"io/ioutil"
+	"testing"
+)
+
+// TestNonDarwinStubs makes sure every darwin only API has a stub so the
+// package keeps compiling on other platforms. New darwin only functions must
+// be added here.
+func TestNonDarwinStubs(t *testing.T) {
+	b := &BookmarkData{}
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"IsAliasErr", func() error { _, err := IsAliasErr("src"); return err }},
+		{"RemoveAlias", func() error { return RemoveAlias("src") }},
+		{"Alias", func() error { return Alias("src", "dst") }},
+		{"AliasWithOpts", func() error {
+			return AliasWithOpts("src", "dst", BookmarkOpts{IconLocation: &image.Point{}})
+		}},
+		{"CreateAliasFile", func() error { return CreateAliasFile("src", "dst") }},
+		{"WriteFileloc", func() error { return WriteFileloc("src", "dst") }},
+		{"NewBookmarkData", func() error { _, err := NewBookmarkData("src"); return err }},
+		{"BookmarkTo", func() error { return BookmarkTo("src", ioutil.Discard) }},
+		{"ConvertSymlink", func() error { return ConvertSymlink("src") }},
+		{"RelativeAlias", func() error { return RelativeAlias("/target", "/", "dst") }},
+		{"NewAliasRecord", func() error { _, err := NewAliasRecord("src"); return err }},
+		{"NewAliasRecordWithOpts", func() error {
+			_, err := NewAliasRecordWithOpts("src", BookmarkOpts{Strict: true})
+			return err
+		}},
+		{"BookmarkFileInfo", func() error { _, err := BookmarkFileInfo("src"); return err }},
+		{"OpenTarget", func() error { _, err := OpenTarget("src"); return err }},
+		{"ResolveFileloc", func() error { _, err := ResolveFileloc("src"); return err }},
+		{"BookmarkData.Resolve", func() error { _, _, err := b.Resolve(); return err }},
+		{"BookmarkData.Matches", func() error { _, err := b.Matches("src"); return err }},
+		{"BookmarkData.IsStale", func() error { _, err := b.IsStale(); return err }},
+		{"BookmarkData.SetVolume", func() error { return b.SetVolume("/") }},
+	}
+	for _, tt := range tests {
+		if err := tt.call(); !errors.Is(err, ErrNotDarwin) {
+			t.Errorf("%s returned %v, want ErrNotDarwin", tt.name, err)
+		}
+	}
+	if IsAlias("src") {
+		t.Error("IsAlias returned true")
+	}
+}
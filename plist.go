@@ -0,0 +1,156 @@
+package cocoa
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// readPlist is a minimal XML property list reader returning the root value.
+// Dictionaries are decoded as map[string]interface{}, arrays as
+// []interface{}, data as []byte, integers as int64, reals as float64, dates
+// as time.Time and booleans as bool.
+// Binary property lists aren't supported.
+func readPlist(r io.Reader) (interface{}, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the property list - %s", err)
+	}
+	if bytes.HasPrefix(data, []byte("bplist")) {
+		return nil, fmt.Errorf("binary property lists aren't supported")
+	}
+	d := xml.NewDecoder(bytes.NewReader(data))
+	// the doctype is ignored, the plist element wraps the root value
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid property list - %s", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			if start.Name.Local != "plist" {
+				return nil, fmt.Errorf("invalid property list - unexpected root element %s", start.Name.Local)
+			}
+			break
+		}
+	}
+	start, err := nextPlistElement(d)
+	if err != nil {
+		return nil, err
+	}
+	if start == nil {
+		return nil, fmt.Errorf("invalid property list - missing root value")
+	}
+	return decodePlistValue(d, *start)
+}
+
+// nextPlistElement returns the next start element, or nil if the parent
+// element ends first.
+func nextPlistElement(d *xml.Decoder) (*xml.StartElement, error) {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid property list - %s", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			return &t, nil
+		case xml.EndElement:
+			return nil, nil
+		}
+	}
+}
+
+func decodePlistValue(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := map[string]interface{}{}
+		for {
+			keyElem, err := nextPlistElement(d)
+			if err != nil {
+				return nil, err
+			}
+			if keyElem == nil {
+				return dict, nil
+			}
+			if keyElem.Name.Local != "key" {
+				return nil, fmt.Errorf("invalid property list - expected a dictionary key, got %s", keyElem.Name.Local)
+			}
+			var key string
+			if err := d.DecodeElement(&key, keyElem); err != nil {
+				return nil, fmt.Errorf("invalid property list key - %s", err)
+			}
+			valueElem, err := nextPlistElement(d)
+			if err != nil {
+				return nil, err
+			}
+			if valueElem == nil {
+				return nil, fmt.Errorf("invalid property list - missing the value of %s", key)
+			}
+			if dict[key], err = decodePlistValue(d, *valueElem); err != nil {
+				return nil, err
+			}
+		}
+	case "array":
+		array := []interface{}{}
+		for {
+			elem, err := nextPlistElement(d)
+			if err != nil {
+				return nil, err
+			}
+			if elem == nil {
+				return array, nil
+			}
+			value, err := decodePlistValue(d, *elem)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+	case "true", "false":
+		if err := d.Skip(); err != nil {
+			return nil, fmt.Errorf("invalid property list - %s", err)
+		}
+		return start.Name.Local == "true", nil
+	}
+
+	var text string
+	if err := d.DecodeElement(&text, &start); err != nil {
+		return nil, fmt.Errorf("invalid property list %s - %s", start.Name.Local, err)
+	}
+	switch start.Name.Local {
+	case "string":
+		return text, nil
+	case "data":
+		// the base64 data is usually wrapped and indented
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+		if err != nil {
+			return nil, fmt.Errorf("invalid property list data - %s", err)
+		}
+		return data, nil
+	case "integer":
+		i, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid property list integer - %s", err)
+		}
+		return i, nil
+	case "real":
+		f, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid property list real - %s", err)
+		}
+		return f, nil
+	case "date":
+		t, err := time.Parse(time.RFC3339, strings.TrimSpace(text))
+		if err != nil {
+			return nil, fmt.Errorf("invalid property list date - %s", err)
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf("invalid property list - unsupported element %s", start.Name.Local)
+}
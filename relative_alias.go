@@ -0,0 +1,66 @@
+package cocoa
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// relativeBookmark builds a path only bookmark (without CNIDs) pointing to
+// targetPath and whose URL is relative to fromDir.
+// The file system isn't accessed so neither path has to exist.
+func relativeBookmark(targetPath, fromDir string) (*BookmarkData, error) {
+	target, err := filepath.Abs(targetPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the path of the target - %s", err)
+	}
+	from, err := filepath.Abs(fromDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the path of the base directory - %s", err)
+	}
+	rel, err := filepath.Rel(from, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make %s relative to %s - %s", target, from, err)
+	}
+	target = filepath.ToSlash(target)
+	if vol := filepath.VolumeName(target); vol != "" {
+		target = target[len(vol):]
+	}
+	if target == "/" {
+		return nil, fmt.Errorf("can't create a relative alias to the root volume")
+	}
+	from = filepath.ToSlash(from)
+	if vol := filepath.VolumeName(from); vol != "" {
+		from = from[len(vol):]
+	}
+
+	b := &BookmarkData{
+		Path:                pathComponents(target),
+		VolumePath:          "/",
+		VolumeIsRoot:        true,
+		VolumeURL:           filepath.ToSlash(rel),
+		VolumeURLIsRelative: true,
+		CreationOptions:     uint32(BookmarkCreationSuitableForBookmarkFile),
+	}
+	if len(b.Path) > 1 {
+		b.ContainingFolderIDX = uint32(len(b.Path)) - 2
+	}
+	// the components shared with the base directory come from the base URL
+	fromPath := pathComponents(from)
+	var shared int
+	for shared < len(fromPath) && shared < len(b.Path) && fromPath[shared] == b.Path[shared] {
+		shared++
+	}
+	b.URLLengths = []uint32{uint32(shared), uint32(len(b.Path) - shared)}
+	return b, nil
+}
+
+// RelativeTargetPath returns the path of the target of a bookmark with a
+// relative URL, resolved from baseDir, the directory the URL is relative to.
+// Unlike TargetPath, the result follows baseDir if it was moved or its
+// volume was mounted elsewhere.
+func (b *BookmarkData) RelativeTargetPath(baseDir string) (string, error) {
+	if !b.VolumeURLIsRelative {
+		return "", fmt.Errorf("the bookmark URL %s isn't relative", b.VolumeURL)
+	}
+	return filepath.Join(baseDir, filepath.FromSlash(b.VolumeURL)), nil
+}
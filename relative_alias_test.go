@@ -0,0 +1,70 @@
+package cocoa
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_relativeBookmark(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		fromDir     string
+		wantURL     string
+		wantPath    []string
+		wantLengths []uint32
+	}{
+		{"sibling", "/build/assets/logo.png", "/build/bin", "../assets/logo.png", []string{"build", "assets", "logo.png"}, []uint32{1, 2}},
+		{"child", "/build/assets/logo.png", "/build", "assets/logo.png", []string{"build", "assets", "logo.png"}, []uint32{1, 2}},
+		{"top level", "/logo.png", "/build/bin", "../../logo.png", []string{"logo.png"}, []uint32{0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := relativeBookmark(tt.target, tt.fromDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			buf := &bytes.Buffer{}
+			if err := b.Write(buf); err != nil {
+				t.Fatal(err)
+			}
+			got, err := AliasFromReader(buf)
+			if err != nil {
+				t.Fatalf("failed to decode the relative alias - %s", err)
+			}
+			if !got.VolumeURLIsRelative {
+				t.Error("expected the URL to have the relative subtype")
+			}
+			if got.VolumeURL != tt.wantURL {
+				t.Errorf("VolumeURL = %q, want %q", got.VolumeURL, tt.wantURL)
+			}
+			if !reflect.DeepEqual(got.Path, tt.wantPath) {
+				t.Errorf("Path = %v, want %v", got.Path, tt.wantPath)
+			}
+			if len(got.CNIDPath) != 0 {
+				t.Errorf("expected a path only bookmark, got CNIDs %v", got.CNIDPath)
+			}
+			if !reflect.DeepEqual(got.URLLengths, tt.wantLengths) {
+				t.Errorf("URLLengths = %v, want %v", got.URLLengths, tt.wantLengths)
+			}
+			if got.TargetPath() != tt.target {
+				t.Errorf("TargetPath() = %s, want %s", got.TargetPath(), tt.target)
+			}
+			// the base directory mounted elsewhere
+			moved := filepath.Join("/Volumes/Backup", tt.fromDir)
+			want := filepath.Join("/Volumes/Backup", tt.target)
+			if path, err := got.RelativeTargetPath(moved); err != nil || path != want {
+				t.Errorf("RelativeTargetPath(%s) = %s, %v, want %s", moved, path, err, want)
+			}
+		})
+	}
+}
+
+func TestBookmarkData_RelativeTargetPath_absolute(t *testing.T) {
+	b := &BookmarkData{Path: []string{"logo.png"}, VolumeURL: "file:///"}
+	if _, err := b.RelativeTargetPath("/build"); err == nil {
+		t.Error("expected an error for a bookmark with an absolute URL")
+	}
+}
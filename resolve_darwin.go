@@ -0,0 +1,278 @@
+package cocoa
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mattetti/cocoa/darwin"
+)
+
+// Resolve returns the current on disk path of the bookmark target.
+// The stored path is tried first, if the file isn't found there (or isn't the
+// same file anymore: different CNID or creation date), the CNID path is
+// walked to find where the target was moved to. In this case, the bookmark is
+// reported as stale.
+// If the CNIDs can't be resolved (for instance because the files were
+// restored from a backup), the stored path is used as a fallback when it
+// exists and the bookmark is also reported as stale.
+// ErrVolumeNotMounted is returned if the volume of the target isn't mounted.
+func (b *BookmarkData) Resolve() (path string, stale bool, err error) {
+	if b.VolumePath != "" {
+		if _, err = os.Stat(b.VolumePath); err != nil {
+			return "", false, fmt.Errorf("%w: %s", ErrVolumeNotMounted, b.VolumePath)
+		}
+	}
+	path = b.TargetPath()
+	attrs, statErr := darwin.GetAttrList(path,
+		darwin.AttrListMask{CommonAttr: darwin.ATTR_CMN_CRTIME | darwin.ATTR_CMN_FILEID},
+		make([]byte, 256), 0)
+	if statErr == nil && b.isTarget(attrs) {
+		return path, false, nil
+	}
+
+	if len(b.CNIDPath) == 0 {
+		if statErr == nil {
+			return path, true, nil
+		}
+		return "", false, fmt.Errorf("%s not found and the bookmark doesn't have a CNID path to resolve it", path)
+	}
+	cnidPath, err := b.resolveByCNIDPath()
+	if err != nil {
+		if statErr == nil {
+			return path, true, nil
+		}
+		return "", false, err
+	}
+	return cnidPath, true, nil
+}
+
+// isTarget returns positively if the passed attributes (ATTR_CMN_FILEID and
+// ATTR_CMN_CRTIME) match the CNID and creation date of the target, when
+// they are known.
+func (b *BookmarkData) isTarget(attrs *darwin.AttrList) bool {
+	if len(b.CNIDPath) > 0 && attrs.FileID != b.CNIDPath[len(b.CNIDPath)-1] {
+		return false
+	}
+	if b.FileCreationDate.IsZero() || attrs.CreationTime == nil {
+		return true
+	}
+	return sameCreationDate(attrs.CreationTime.Time(), b.FileCreationDate)
+}
+
+// sameCreationDate compares a file creation date with the one stored in a
+// bookmark. The stored date is a float and loses the nanoseconds precision.
+func sameCreationDate(fileDate, stored time.Time) bool {
+	delta := fileDate.Sub(stored)
+	return delta > -time.Millisecond && delta < time.Millisecond
+}
+
+// OpenTarget opens the target of the alias file found at aliasPath for
+// reading. The target is found using Resolve so it can be opened even if it
+// was moved.
+func OpenTarget(aliasPath string) (*os.File, error) {
+	f, err := os.Open(aliasPath)
+	if err != nil {
+		return nil, err
+	}
+	b, err := AliasFromReader(f)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the alias %s - %s", aliasPath, err)
+	}
+	path, _, err := b.Resolve()
+	if err != nil {
+		return nil, fmt.Errorf("the alias target of %s can't be found - %w", aliasPath, err)
+	}
+	return os.Open(path)
+}
+
+// ResolveFileloc returns the path of the target of the Finder location file
+// (.fileloc or .webloc) found at path. The target is found using Resolve so
+// it can be found even if it was moved.
+func ResolveFileloc(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	b, err := FilelocBookmark(f)
+	f.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the location file %s - %s", path, err)
+	}
+	target, _, err := b.Resolve()
+	if err != nil {
+		return "", fmt.Errorf("the target of %s can't be found - %w", path, err)
+	}
+	return target, nil
+}
+
+// Matches returns positively if the file at the passed path is the bookmark
+// target, even if the path differs. The CNID of the file and the UUID of its
+// volume are compared against the values stored in the bookmark. The volume
+// isn't checked if the bookmark doesn't know its UUID.
+func (b *BookmarkData) Matches(path string) (bool, error) {
+	cnid, ok := b.targetCNID()
+	if !ok {
+		return false, fmt.Errorf("the bookmark doesn't have a target CNID")
+	}
+	ino, err := inode(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to retrieve the file id of %s - %s", path, err)
+	}
+	if ino != cnid {
+		return false, nil
+	}
+	if isBlankUUID(b.VolumeUUID) {
+		return true, nil
+	}
+	uuid, err := volumeUUID(path)
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(uuid, b.VolumeUUID), nil
+}
+
+// IsStale reports whether the file found at the stored target path isn't the
+// bookmark target anymore: the file is missing, its CNID, volume UUID or
+// creation date differ from the stored values or one of the folders leading
+// to it was replaced and doesn't match the CNID path anymore. Unlike Resolve,
+// it doesn't try to find where the target went.
+func (b *BookmarkData) IsStale() (bool, error) {
+	path := b.TargetPath()
+	if _, err := os.Lstat(path); err != nil {
+		if os.IsNotExist(err) {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to stat %s - %s", path, err)
+	}
+	if _, ok := b.targetCNID(); ok {
+		match, err := b.Matches(path)
+		if err != nil {
+			return false, err
+		}
+		if !match {
+			return true, nil
+		}
+	}
+	if changed, err := b.cnidPathChanged(); err != nil || changed {
+		return changed, err
+	}
+	if b.FileCreationDate.IsZero() {
+		return false, nil
+	}
+	attrs, err := darwin.GetAttrList(path,
+		darwin.AttrListMask{CommonAttr: darwin.ATTR_CMN_CRTIME},
+		make([]byte, 256), darwin.FSOPT_NOFOLLOW)
+	if err != nil {
+		return false, fmt.Errorf("failed to retrieve the creation date of %s - %s", path, err)
+	}
+	return !sameCreationDate(attrs.CreationTime.Time(), b.FileCreationDate), nil
+}
+
+// cnidPathChanged reports whether a component of the stored path now has a
+// different file ID than the one stored in the CNID path, or is missing.
+// Symlinks are followed, as when the CNID path is recorded in newBookmark.
+// Bookmarks without a complete CNID path are never reported as changed.
+func (b *BookmarkData) cnidPathChanged() (bool, error) {
+	if len(b.Path) != len(b.CNIDPath) {
+		return false, nil
+	}
+	dir := "/"
+	for i, cnid := range b.CNIDPath {
+		dir = filepath.Join(dir, b.Path[i])
+		ino, err := inode(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return true, nil
+			}
+			return false, fmt.Errorf("failed to retrieve the file id of %s - %s", dir, err)
+		}
+		if ino != cnid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// volumeUUID returns the UUID of the volume the passed path is on.
+func volumeUUID(path string) (string, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return "", fmt.Errorf("failed to read the file system stats of %s - %s", path, err)
+	}
+	attrs, err := darwin.GetAttrList(statfsString(stat.Mntonname[:]),
+		darwin.AttrListMask{VolAttr: darwin.ATTR_VOL_UUID},
+		make([]byte, 256), 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve the volume uuid of %s - %s", path, err)
+	}
+	return attrs.StringVolUUID(), nil
+}
+
+// resolveByCNIDPath rebuilds the path of the target by walking the CNID path
+// from the root and looking up the current name of each CNID in its parent
+// directory.
+func (b *BookmarkData) resolveByCNIDPath() (string, error) {
+	if len(b.Path) != len(b.CNIDPath) {
+		return "", fmt.Errorf("can't resolve by CNID, the path has %d components but the CNID path has %d",
+			len(b.Path), len(b.CNIDPath))
+	}
+
+	dir := "/"
+	for i, cnid := range b.CNIDPath {
+		// fast path, the node wasn't renamed
+		candidate := filepath.Join(dir, b.Path[i])
+		if ino, err := inode(candidate); err == nil && ino == cnid {
+			dir = candidate
+			continue
+		}
+		name, err := nameByCNID(dir, cnid)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s - %s", b.Path[i], err)
+		}
+		dir = filepath.Join(dir, name)
+	}
+
+	return dir, nil
+}
+
+// nameByCNID returns the name of the entry of dir with the passed CNID.
+func nameByCNID(dir string, cnid uint64) (string, error) {
+	// look the name up by id if the volume supports it
+	attrs, err := darwin.GetAttrByFileID(dir, cnid, darwin.AttrListMask{CommonAttr: darwin.ATTR_CMN_NAME})
+	if err == nil && attrs.Name != "" {
+		if ino, err := inode(filepath.Join(dir, attrs.Name)); err == nil && ino == cnid {
+			return attrs.Name, nil
+		}
+	}
+
+	// otherwise look for the CNID in the parent directory
+	f, err := os.Open(dir)
+	if err != nil {
+		return "", err
+	}
+	names, err := f.Readdirnames(-1)
+	f.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to list %s - %s", dir, err)
+	}
+	for _, name := range names {
+		if ino, err := inode(filepath.Join(dir, name)); err == nil && ino == cnid {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("CNID %d not found in %s", cnid, dir)
+}
+
+// inode returns the file id of the file at the passed path.
+func inode(path string) (uint64, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return fi.Sys().(*syscall.Stat_t).Ino, nil
+}
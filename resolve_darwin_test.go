@@ -0,0 +1,357 @@
+package cocoa
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBookmarkData_Resolve(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	// the temp dir usually is behind a symlink (/var -> /private/var)
+	if tmpDir, err = filepath.EvalSymlinks(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(tmpDir, "parent", "child")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(dir, "target.txt")
+	if err := ioutil.WriteFile(src, []byte("cocoa"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(tmpDir, "target alias")
+	if err := Alias(src, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := AliasFromReader(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path, stale, err := b.Resolve()
+	if err != nil {
+		t.Fatalf("BookmarkData.Resolve() error = %v", err)
+	}
+	if path != src || stale {
+		t.Errorf("BookmarkData.Resolve() = %s, %v, want %s, false", path, stale, src)
+	}
+
+	// rename an intermediate directory
+	renamed := filepath.Join(tmpDir, "renamed")
+	if err := os.Rename(filepath.Join(tmpDir, "parent"), renamed); err != nil {
+		t.Fatal(err)
+	}
+	path, stale, err = b.Resolve()
+	if err != nil {
+		t.Fatalf("BookmarkData.Resolve() error = %v", err)
+	}
+	if want := filepath.Join(renamed, "child", "target.txt"); path != want || !stale {
+		t.Errorf("BookmarkData.Resolve() = %s, %v, want %s, true", path, stale, want)
+	}
+}
+
+func TestOpenTarget(t *testing.T) {
+	tmpDir, src, _ := newTestAlias(t)
+	dst := filepath.Join(tmpDir, "target alias")
+	read := func() (string, error) {
+		f, err := OpenTarget(dst)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		data, err := ioutil.ReadAll(f)
+		return string(data), err
+	}
+
+	if got, err := read(); err != nil || got != "cocoa" {
+		t.Errorf("OpenTarget() read %q, %v, want cocoa", got, err)
+	}
+	// moved target
+	moved := filepath.Join(tmpDir, "moved.txt")
+	if err := os.Rename(src, moved); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := read(); err != nil || got != "cocoa" {
+		t.Errorf("OpenTarget() read %q, %v after moving the target, want cocoa", got, err)
+	}
+	// broken alias
+	if err := os.Remove(moved); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenTarget(dst); err == nil {
+		t.Error("expected an error opening the target of a broken alias")
+	}
+	if _, err := OpenTarget(filepath.Join(tmpDir, "missing alias")); err == nil {
+		t.Error("expected an error opening a missing alias")
+	}
+}
+
+func TestResolveFileloc(t *testing.T) {
+	tmpDir, src := newTestTarget(t)
+	fileloc := filepath.Join(tmpDir, "target.fileloc")
+	plist := `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>URL</key>
+	<string>file://` + src + `</string>
+</dict>
+</plist>`
+	if err := ioutil.WriteFile(fileloc, []byte(plist), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ResolveFileloc(fileloc)
+	if err != nil {
+		t.Fatalf("ResolveFileloc() error = %v", err)
+	}
+	if got != src {
+		t.Errorf("ResolveFileloc() = %s, want %s", got, src)
+	}
+
+	os.Remove(src)
+	if _, err := ResolveFileloc(fileloc); err == nil {
+		t.Error("expected an error resolving a missing target")
+	}
+}
+
+func TestWriteFileloc(t *testing.T) {
+	tmpDir, src := newTestTarget(t)
+	fileloc := filepath.Join(tmpDir, "target.fileloc")
+	if err := WriteFileloc(src, fileloc); err != nil {
+		t.Fatalf("WriteFileloc() error = %v", err)
+	}
+	got, err := ResolveFileloc(fileloc)
+	if err != nil {
+		t.Fatalf("ResolveFileloc() error = %v", err)
+	}
+	if got != src {
+		t.Errorf("ResolveFileloc() = %s, want %s", got, src)
+	}
+
+	// the bookmark finds the moved target
+	moved := filepath.Join(tmpDir, "moved.txt")
+	if err := os.Rename(src, moved); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = ResolveFileloc(fileloc); err != nil || got != moved {
+		t.Errorf("ResolveFileloc() = %s, %v, want %s", got, err, moved)
+	}
+}
+
+func TestBookmarkData_Matches(t *testing.T) {
+	tmpDir, src, b := newTestAlias(t)
+	other := filepath.Join(tmpDir, "other.txt")
+	if err := ioutil.WriteFile(other, []byte("cocoa"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// same file, different path
+	link := filepath.Join(tmpDir, "link.txt")
+	if err := os.Link(src, link); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{src, true},
+		{link, true},
+		{other, false},
+	}
+	for _, tt := range tests {
+		got, err := b.Matches(tt.path)
+		if err != nil {
+			t.Fatalf("BookmarkData.Matches(%s) error = %v", tt.path, err)
+		}
+		if got != tt.want {
+			t.Errorf("BookmarkData.Matches(%s) = %t, want %t", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestBookmarkData_IsStale(t *testing.T) {
+	tmpDir, src, b := newTestAlias(t)
+
+	stale, err := b.IsStale()
+	if err != nil {
+		t.Fatalf("BookmarkData.IsStale() error = %v", err)
+	}
+	if stale {
+		t.Error("expected the unchanged target not to be stale")
+	}
+
+	// moved
+	moved := filepath.Join(tmpDir, "moved.txt")
+	if err := os.Rename(src, moved); err != nil {
+		t.Fatal(err)
+	}
+	if stale, err = b.IsStale(); err != nil {
+		t.Fatalf("BookmarkData.IsStale() error = %v", err)
+	}
+	if !stale {
+		t.Error("expected the moved target to be stale")
+	}
+
+	// replaced by another file at the same path
+	if err := ioutil.WriteFile(src, []byte("cocoa"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if stale, err = b.IsStale(); err != nil {
+		t.Fatalf("BookmarkData.IsStale() error = %v", err)
+	}
+	if !stale {
+		t.Error("expected the replaced target to be stale")
+	}
+}
+
+func TestBookmarkData_IsStale_symlinkedFolder(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "cocoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	dir := filepath.Join(tmpDir, "folder")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(tmpDir, "link")
+	if err := os.Symlink(dir, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "target.txt"), []byte("cocoa"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// the temp dir itself isn't resolved either, it usually also is behind a
+	// symlink (/var -> /private/var)
+	b, err := NewBookmarkData(filepath.Join(link, "target.txt"))
+	if err != nil {
+		t.Fatalf("NewBookmarkData() error = %v", err)
+	}
+	stale, err := b.IsStale()
+	if err != nil {
+		t.Fatalf("BookmarkData.IsStale() error = %v", err)
+	}
+	if stale {
+		t.Error("expected the target aliased through a symlinked folder not to be stale")
+	}
+}
+
+func TestBookmarkData_IsStale_replacedFolder(t *testing.T) {
+	dir, src, b := newTestAlias(t)
+	// replace the containing folder but move the target back in it
+	old := dir + ".old"
+	if err := os.Rename(dir, old); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(old)
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(old, filepath.Base(src)), src); err != nil {
+		t.Fatal(err)
+	}
+	if match, err := b.Matches(src); err != nil || !match {
+		t.Fatalf("BookmarkData.Matches() = %t, %v, expected the target itself to match", match, err)
+	}
+	stale, err := b.IsStale()
+	if err != nil {
+		t.Fatalf("BookmarkData.IsStale() error = %v", err)
+	}
+	if !stale {
+		t.Error("expected the target in a replaced folder to be stale")
+	}
+}
+
+func TestBookmarkData_IsStale_nonRootVolume(t *testing.T) {
+	dir, src, b := newTestAlias(t)
+	// pretend the target is on a volume mounted at dir
+	b.VolumePath = dir
+	b.VolumeIsRoot = false
+	stale, err := b.IsStale()
+	if err != nil {
+		t.Fatalf("BookmarkData.IsStale() error = %v", err)
+	}
+	if stale {
+		t.Errorf("expected the unchanged target at %s not to be stale", src)
+	}
+
+	if err := os.Remove(src); err != nil {
+		t.Fatal(err)
+	}
+	if stale, err = b.IsStale(); err != nil || !stale {
+		t.Errorf("BookmarkData.IsStale() = %t, %v, expected the removed target to be stale", stale, err)
+	}
+}
+
+func TestBookmarkData_Resolve_unmountedVolume(t *testing.T) {
+	b := &BookmarkData{
+		Path:       []string{"Volumes", "cocoa-unmounted", "file.wav"},
+		CNIDPath:   []uint64{2, 3, 4},
+		VolumePath: "/Volumes/cocoa-unmounted",
+	}
+	if _, _, err := b.Resolve(); !errors.Is(err, ErrVolumeNotMounted) {
+		t.Errorf("BookmarkData.Resolve() error = %v, want ErrVolumeNotMounted", err)
+	}
+}
+
+func TestBookmarkData_Resolve_nonRootVolume(t *testing.T) {
+	dir, src, b := newTestAlias(t)
+	// pretend the target is on a volume mounted at dir, the path components
+	// still start at the file system root like for a real external volume.
+	b.VolumePath = dir
+	b.VolumeIsRoot = false
+	path, stale, err := b.Resolve()
+	if err != nil {
+		t.Fatalf("BookmarkData.Resolve() error = %v", err)
+	}
+	if path != src || stale {
+		t.Errorf("BookmarkData.Resolve() = %s, %v, want %s, false", path, stale, src)
+	}
+}
+
+func TestBookmarkData_Resolve_creationDate(t *testing.T) {
+	_, src, b := newTestAlias(t)
+
+	// the CNID matches but not the creation date, the CNID walk confirms it
+	b.FileCreationDate = b.FileCreationDate.Add(-time.Hour)
+	path, stale, err := b.Resolve()
+	if err != nil {
+		t.Fatalf("BookmarkData.Resolve() error = %v", err)
+	}
+	if path != src || !stale {
+		t.Errorf("BookmarkData.Resolve() = %s, %v, want %s, true", path, stale, src)
+	}
+}
+
+func TestBookmarkData_Resolve_invalidCNIDs(t *testing.T) {
+	_, src, b := newTestAlias(t)
+	if b.TargetPath() != src {
+		t.Fatalf("expected the alias to contain the absolute path %s, got %s", src, b.TargetPath())
+	}
+
+	// simulate files restored from a backup, none of the CNIDs are valid
+	for i := range b.CNIDPath {
+		b.CNIDPath[i] = 1<<40 + uint64(i)
+	}
+	path, stale, err := b.Resolve()
+	if err != nil {
+		t.Fatalf("BookmarkData.Resolve() error = %v", err)
+	}
+	if path != src || !stale {
+		t.Errorf("BookmarkData.Resolve() = %s, %v, want %s, true", path, stale, src)
+	}
+}
@@ -0,0 +1,138 @@
+package cocoa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattetti/cocoa/darwin"
+)
+
+// blankUUID is the volume UUID returned when the volume doesn't have one.
+const blankUUID = "00000000-0000-0000-0000-000000000000"
+
+// isBlankUUID reports whether the passed volume UUID is missing.
+func isBlankUUID(uuid string) bool {
+	return uuid == "" || uuid == blankUUID
+}
+
+// uuidString formats the UUID the way volume UUIDs are stored, in uppercase.
+func uuidString(uuid [16]byte) string {
+	return fmt.Sprintf("%X-%X-%X-%X-%X", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+}
+
+// isFATFileSystem reports whether the passed statfs file system type is a FAT
+// file system (MS-DOS FAT12/16/32 or exFAT), usually found on external drives
+// and memory cards.
+func isFATFileSystem(fsType string) bool {
+	return fsType == "msdos" || fsType == "exfat"
+}
+
+// setVolumeAttrs sets the volume information of the bookmark using the
+// passed attributes of the volume mounted at volPath. When the attributes
+// couldn't be read (nil), fallback values are used and the guessed fields are
+// listed in Defaulted. fsSize is the size of the volume reported by statfs,
+// used when the attributes don't have the volume size. A zero size means it's
+// unknown. The volume properties depend on b.FileSystemType.
+func (b *BookmarkData) setVolumeAttrs(volPath string, mountFlags uint32, fsSize int64, volumeAttrs *darwin.AttrList) {
+	// the defaulted volume fields of a previous volume don't apply anymore
+	defaulted := b.Defaulted[:0]
+	for _, field := range b.Defaulted {
+		switch field {
+		case "VolumeName", "VolumeCreationDate", "VolumeUUID", "VolumeSize":
+		default:
+			defaulted = append(defaulted, field)
+		}
+	}
+	b.Defaulted = defaulted
+	if volumeAttrs == nil {
+		volumeAttrs = &darwin.AttrList{
+			VolName:      strings.Replace(volPath, "/Volumes/", "", 1),
+			CreationTime: &darwin.TimeSpec{},
+			MountFlags:   mountFlags,
+		}
+		b.Defaulted = append(b.Defaulted, "VolumeName", "VolumeCreationDate", "VolumeUUID")
+	}
+	volSize := volumeAttrs.VolSize
+	if volSize == 0 {
+		volSize = fsSize
+	}
+	if volSize == 0 {
+		b.Defaulted = append(b.Defaulted, "VolumeSize")
+	}
+
+	b.VolumePath = volPath
+	b.VolumeIsRoot = volPath == "/"
+	b.VolumeURL = "file://" + volPath
+	b.VolumeName = volumeAttrs.VolName
+	b.VolumeSize = volSize
+	b.VolumeCreationDate = time.Time{}
+	// a zero creation time means it's unknown
+	if volumeAttrs.CreationTime != nil && *volumeAttrs.CreationTime != (darwin.TimeSpec{}) {
+		b.VolumeCreationDate = volumeAttrs.CreationTime.Time()
+	}
+	b.VolumeUUID = ""
+	if uuid := volumeAttrs.StringVolUUID(); !isBlankUUID(uuid) {
+		b.VolumeUUID = strings.ToUpper(uuid)
+	}
+
+	// volume properties
+	bb := &bytes.Buffer{}
+	// if bookmark.VolumeIsRoot {
+	// 0x81, 0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0,
+	volFlags := uint64(0x81 | darwin.KCFURLVolumeSupportsPersistentIDs)
+	if isFATFileSystem(b.FileSystemType) {
+		// like Finder on exFAT, FAT volumes are flagged as external drives
+		// without persistent IDs. msdos volumes are assumed to get the same
+		// flags, no msdos bookmark was checked.
+		volFlags = darwin.KCFURLVolumeIsLocal | darwin.KCFURLVolumeIsExternal
+	}
+	if volumeAttrs.MountFlags&darwin.MNT_RDONLY > 0 {
+		volFlags |= darwin.KCFURLVolumeIsReadOnly
+	}
+	binary.Write(bb, binary.LittleEndian, volFlags)
+	// 0xef, 0x13, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0,
+	binary.Write(bb, binary.LittleEndian, uint64(0x13ef|darwin.KCFURLVolumeSupportsPersistentIDs))
+	// } else {
+	// 	binary.Write(bb, binary.LittleEndian, uint64(darwin.KCFURLVolumeIsLocal|darwin.KCFURLVolumeIsExternal))
+	// 	binary.Write(bb, binary.LittleEndian, uint64(0x13ef|darwin.KCFURLVolumeSupportsPersistentIDs))
+	// }
+	bb.Write([]byte{0xef, 0x13, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0})
+	// binary.Write(bb, binary.LittleEndian, uint64(0))
+	b.VolumeProperties = bb.Bytes()
+}
+
+// VolumeFlags returns the volume property flags of the target volume, see the
+// darwin.KCFURLVolume* constants.
+func (b *BookmarkData) VolumeFlags() uint64 {
+	return propertyFlags(b.VolumeProperties)
+}
+
+// VolumeIsLocal reports whether the target volume is a local volume.
+func (b *BookmarkData) VolumeIsLocal() bool {
+	return b.VolumeFlags()&darwin.KCFURLVolumeIsLocal != 0
+}
+
+// VolumeIsExternal reports whether the target volume is an external drive.
+func (b *BookmarkData) VolumeIsExternal() bool {
+	return b.VolumeFlags()&darwin.KCFURLVolumeIsExternal != 0
+}
+
+// VolumeIsReadOnly reports whether the target volume is mounted read only.
+func (b *BookmarkData) VolumeIsReadOnly() bool {
+	return b.VolumeFlags()&darwin.KCFURLVolumeIsReadOnly != 0
+}
+
+// VolumeIsDiskImage reports whether the target volume is a mounted disk image,
+// see DiskImagePath.
+func (b *BookmarkData) VolumeIsDiskImage() bool {
+	return b.VolumeFlags()&darwin.KCFURLVolumeIsDiskImage != 0
+}
+
+// VolumeSupportsPersistentIDs reports whether the file IDs of the target
+// volume are persistent, the CNID path can then be used to find the target.
+func (b *BookmarkData) VolumeSupportsPersistentIDs() bool {
+	return b.VolumeFlags()&darwin.KCFURLVolumeSupportsPersistentIDs != 0
+}
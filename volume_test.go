@@ -0,0 +1,212 @@
+package cocoa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/mattetti/cocoa/darwin"
+)
+
+func TestBookmarkData_setVolumeAttrs(t *testing.T) {
+	// volume attributes unavailable
+	b := &BookmarkData{}
+	b.setVolumeAttrs("/Volumes/MattSplice", darwin.MNT_RDONLY, 0, nil)
+	want := []string{"VolumeName", "VolumeCreationDate", "VolumeUUID", "VolumeSize"}
+	if !reflect.DeepEqual(b.Defaulted, want) {
+		t.Errorf("Defaulted = %v, want %v", b.Defaulted, want)
+	}
+	if b.VolumeName != "MattSplice" {
+		t.Errorf("VolumeName = %s, want MattSplice", b.VolumeName)
+	}
+	if b.VolumeUUID != "" {
+		t.Errorf("VolumeUUID = %q, expected an empty UUID", b.VolumeUUID)
+	}
+	if !b.VolumeCreationDate.IsZero() {
+		t.Errorf("VolumeCreationDate = %v, expected a zero time", b.VolumeCreationDate)
+	}
+	if flags := binary.LittleEndian.Uint64(b.VolumeProperties); flags&darwin.KCFURLVolumeIsReadOnly == 0 {
+		t.Errorf("expected the volume to be flagged as read only, got %#x", flags)
+	}
+
+	b = &BookmarkData{}
+	b.setVolumeAttrs("/", 0, 64, &darwin.AttrList{
+		VolName:      "Macintosh HD",
+		VolSize:      42,
+		CreationTime: &darwin.TimeSpec{Sec: 1500000000},
+		VolUUID:      [16]byte{0xa},
+	})
+	if len(b.Defaulted) != 0 {
+		t.Errorf("Defaulted = %v, expected no defaulted fields", b.Defaulted)
+	}
+	if b.VolumeName != "Macintosh HD" || b.VolumeSize != 42 || !b.VolumeIsRoot {
+		t.Errorf("unexpected volume information %+v", b)
+	}
+}
+
+func TestBookmarkData_setVolumeAttrs_statfsSize(t *testing.T) {
+	tests := []struct {
+		name          string
+		fsSize        int64
+		volumeAttrs   *darwin.AttrList
+		want          int64
+		wantDefaulted bool
+	}{
+		{"no attributes", 499963174912, nil, 499963174912, false},
+		{"missing size attribute", 499963174912, &darwin.AttrList{VolName: "Audio"}, 499963174912, false},
+		{"size attribute", 499963174912, &darwin.AttrList{VolName: "Audio", VolSize: 42}, 42, false},
+		{"unknown size", 0, &darwin.AttrList{VolName: "Audio"}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BookmarkData{}
+			b.setVolumeAttrs("/Volumes/Audio", 0, tt.fsSize, tt.volumeAttrs)
+			if b.VolumeSize != tt.want {
+				t.Errorf("VolumeSize = %d, want %d", b.VolumeSize, tt.want)
+			}
+			defaulted := false
+			for _, field := range b.Defaulted {
+				defaulted = defaulted || field == "VolumeSize"
+			}
+			if defaulted != tt.wantDefaulted {
+				t.Errorf("VolumeSize defaulted = %t, want %t (%v)", defaulted, tt.wantDefaulted, b.Defaulted)
+			}
+		})
+	}
+}
+
+func TestBookmarkData_setVolumeAttrs_resetDefaulted(t *testing.T) {
+	b := &BookmarkData{Defaulted: []string{"UserName"}}
+	b.setVolumeAttrs("/Volumes/MattSplice", 0, 0, nil)
+	b.setVolumeAttrs("/Volumes/MattSplice", 0, 0, nil)
+	want := []string{"UserName", "VolumeName", "VolumeCreationDate", "VolumeUUID", "VolumeSize"}
+	if !reflect.DeepEqual(b.Defaulted, want) {
+		t.Errorf("Defaulted = %v, want %v", b.Defaulted, want)
+	}
+
+	// moved to a volume with readable attributes
+	b.setVolumeAttrs("/", 0, 64, &darwin.AttrList{VolName: "Macintosh HD", VolSize: 42})
+	if want := []string{"UserName"}; !reflect.DeepEqual(b.Defaulted, want) {
+		t.Errorf("Defaulted = %v, want %v", b.Defaulted, want)
+	}
+}
+
+func TestBookmarkData_setVolumeAttrs_exFAT(t *testing.T) {
+	f, err := os.Open("fixtures/exFATAlias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	want, err := AliasFromReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &BookmarkData{FileSystemType: "exfat"}
+	var uuid [16]byte
+	copy(uuid[:], []byte{0x4d, 0x0b, 0xbf, 0xf1, 0xbb, 0x47, 0x37, 0xdc, 0xa9, 0x74, 0x6b, 0x23, 0xef, 0x9e, 0x52, 0xdd})
+	b.setVolumeAttrs(want.VolumePath, 0, 0, &darwin.AttrList{
+		VolName: want.VolumeName,
+		VolSize: want.VolumeSize,
+		// exFAT doesn't store the creation date of the volume
+		CreationTime: &darwin.TimeSpec{},
+		VolUUID:      uuid,
+	})
+	if b.VolumeUUID != want.VolumeUUID {
+		t.Errorf("VolumeUUID = %s, want %s", b.VolumeUUID, want.VolumeUUID)
+	}
+	if b.VolumeName != want.VolumeName || b.VolumeSize != want.VolumeSize || b.VolumeIsRoot {
+		t.Errorf("unexpected volume information %+v", b)
+	}
+	if !reflect.DeepEqual(b.VolumeProperties, want.VolumeProperties) {
+		t.Errorf("VolumeProperties = %x, want %x", b.VolumeProperties, want.VolumeProperties)
+	}
+	if len(b.Defaulted) != 0 || !b.VolumeCreationDate.IsZero() {
+		t.Errorf("Defaulted = %v, VolumeCreationDate = %v, expected no defaulted fields and a zero date", b.Defaulted, b.VolumeCreationDate)
+	}
+
+	// the resulting bookmark decodes like the Finder one
+	b.Path = want.Path
+	b.CNIDPath = want.CNIDPath
+	b.FileProperties = want.FileProperties
+	b.FileCreationDate = want.FileCreationDate
+	buf := &bytes.Buffer{}
+	if err := b.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := AliasFromReader(buf)
+	if err != nil {
+		t.Fatalf("AliasFromReader() error = %v", err)
+	}
+	if !got.Equal(want) || got.VolumeSize != want.VolumeSize ||
+		!reflect.DeepEqual(got.VolumeProperties, want.VolumeProperties) {
+		t.Errorf("decoded bookmark = %+v, want %+v", got, want)
+	}
+}
+
+func TestBookmarkData_setVolumeAttrs_msdos(t *testing.T) {
+	// there is no msdos fixture, only check the volume is flagged as an
+	// external drive like exFAT ones
+	b := &BookmarkData{FileSystemType: "msdos"}
+	b.setVolumeAttrs("/Volumes/NO NAME", 0, 0, &darwin.AttrList{VolName: "NO NAME", VolSize: 42})
+	flags := binary.LittleEndian.Uint64(b.VolumeProperties)
+	if flags&darwin.KCFURLVolumeIsExternal == 0 || flags&darwin.KCFURLVolumeSupportsPersistentIDs != 0 {
+		t.Errorf("volume flags = %#x, expected an external volume without persistent IDs", flags)
+	}
+}
+
+func TestBookmarkData_volumeFlags(t *testing.T) {
+	tests := []struct {
+		fixture         string
+		local, external bool
+		diskImage       bool
+		persistentIDs   bool
+		readOnly        bool
+	}{
+		{fixture: "fixtures/alias", local: true, persistentIDs: true},
+		{fixture: "fixtures/exFATAlias", local: true, external: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			f, err := os.Open(tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			b, err := AliasFromReader(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.VolumeFlags() != binary.LittleEndian.Uint64(b.VolumeProperties) {
+				t.Errorf("VolumeFlags() = %#x, want the first word of %x", b.VolumeFlags(), b.VolumeProperties)
+			}
+			if got := b.VolumeIsLocal(); got != tt.local {
+				t.Errorf("VolumeIsLocal() = %t, want %t", got, tt.local)
+			}
+			if got := b.VolumeIsExternal(); got != tt.external {
+				t.Errorf("VolumeIsExternal() = %t, want %t", got, tt.external)
+			}
+			if got := b.VolumeIsDiskImage(); got != tt.diskImage {
+				t.Errorf("VolumeIsDiskImage() = %t, want %t", got, tt.diskImage)
+			}
+			if got := b.VolumeSupportsPersistentIDs(); got != tt.persistentIDs {
+				t.Errorf("VolumeSupportsPersistentIDs() = %t, want %t", got, tt.persistentIDs)
+			}
+			if got := b.VolumeIsReadOnly(); got != tt.readOnly {
+				t.Errorf("VolumeIsReadOnly() = %t, want %t", got, tt.readOnly)
+			}
+		})
+	}
+
+	b := &BookmarkData{}
+	b.setVolumeAttrs("/Volumes/MattSplice", darwin.MNT_RDONLY, 0, nil)
+	if !b.VolumeIsReadOnly() {
+		t.Error("VolumeIsReadOnly() = false for a read only volume")
+	}
+	// missing properties
+	if b := (&BookmarkData{}); b.VolumeFlags() != 0 || b.VolumeIsLocal() {
+		t.Errorf("VolumeFlags() = %#x, expected no flags", b.VolumeFlags())
+	}
+}